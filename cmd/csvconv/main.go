@@ -0,0 +1,96 @@
+// Command csvconv converts tabular data between CSV, TSV and JSON
+// formats, using the `csv` package.
+//
+// Usage:
+//
+//   csvconv [-from format] [-to format] [-typed] [-detect] [file]
+//
+// The data is read from the file (stdin if omitted or `-`) and written to
+// stdout. Formats are `csv`, `tsv`, `jsonl` (JSON Lines), `json` (array
+// of objects) and `columns` (object of column arrays). Nested JSON
+// objects are flattened into dotted column names.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"golib/csv"
+)
+
+var errUsage = errors.New("usage: csvconv [-from csv|tsv|jsonl|json|columns] [-to csv|tsv|jsonl|json|columns] [-typed] [-detect] [file]")
+
+var jsonFormats = map[string]csv.JSONFormat{
+	"jsonl":   csv.JSONLines,
+	"json":    csv.JSONArray,
+	"columns": csv.JSONColumns,
+}
+
+var separators = map[string]string{
+	"csv": ",",
+	"tsv": "\t",
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("csvconv", flag.ContinueOnError)
+	from := flags.String("from", "csv", "input format")
+	to := flags.String("to", "jsonl", "output format")
+	typed := flags.Bool("typed", false, "write numbers and booleans as such in JSON (see `csv.JSONOptions`)")
+	detect := flags.Bool("detect", false, "detect the CSV separator, quote, encoding and header")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errUsage
+	}
+
+	r := stdin
+	if flags.NArg() == 1 && flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("failed to open file: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	table, err := read(r, *from, *detect)
+	if err != nil {
+		return err
+	}
+	return write(table, stdout, *to, *typed)
+}
+
+func read(r io.Reader, format string, detect bool) (*csv.Table, error) {
+	if sep, ok := separators[format]; ok {
+		opts := csv.Options{Detect: detect}
+		if !detect {
+			opts.Separator = sep
+		}
+		return csv.ParseColumnsFrom(r, opts)
+	}
+	if jsonFormat, ok := jsonFormats[format]; ok {
+		return csv.ReadJSON(r, jsonFormat)
+	}
+	return nil, fmt.Errorf("unknown input format `%s`\n%v", format, errUsage)
+}
+
+func write(table *csv.Table, w io.Writer, format string, typed bool) error {
+	if sep, ok := separators[format]; ok {
+		return csv.NewWriter(w, csv.WriterOptions{Separator: sep, LineEnding: "\n"}).WriteTable(table)
+	}
+	if jsonFormat, ok := jsonFormats[format]; ok {
+		return table.WriteJSON(w, csv.JSONOptions{Format: jsonFormat, Typed: typed})
+	}
+	return fmt.Errorf("unknown output format `%s`\n%v", format, errUsage)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		args     []string
+		input    string
+		expected string
+	}{
+		{nil, "id,name\n1,John\n", `{"id":"1","name":"John"}` + "\n"},
+		{[]string{"-to", "json", "-typed"}, "id,name\n1,John\n", `[{"id":1,"name":"John"}]` + "\n"},
+		{[]string{"-from", "tsv", "-to", "csv"}, "id\tname\n1\tDoe, John\n", "id,name\n1,\"Doe, John\"\n"},
+		{[]string{"-detect", "-to", "columns"}, "id;name\n1;John\n2;Jane\n", `{"id":["1","2"],"name":["John","Jane"]}` + "\n"},
+		{[]string{"-from", "jsonl", "-to", "tsv"}, `{"id":1,"user":{"name":"John"}}`, "id\tuser.name\n1\tJohn\n"},
+	}
+	for _, c := range cases {
+		var stdout bytes.Buffer
+		if err := run(c.args, strings.NewReader(c.input), &stdout); err != nil {
+			t.Fatalf("failed to convert with arguments %v: %v", c.args, err)
+		}
+		if stdout.String() != c.expected {
+			t.Errorf("expected output `%s` with arguments %v, got `%s`", c.expected, c.args, stdout.String())
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	cases := [][]string{
+		{"-from", "xml"},
+		{"-to", "xml"},
+		{"a.csv", "b.csv"},
+		{"missing.csv"},
+	}
+	for _, args := range cases {
+		if err := run(args, strings.NewReader("a\n1\n"), ioutil.Discard); err == nil {
+			t.Errorf("expected an error for arguments %v", args)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Command golib-s3 exposes the `s3` wrapper on the command-line, so the
+// same code paths as the services can be used for operations.
+//
+// Usage:
+//
+//   golib-s3 [flags] ls [-l] [prefix]      list keys (or objects with -l) as JSON
+//   golib-s3 [flags] get <key>             stream the object's content to stdout
+//   golib-s3 [flags] put <key> [file]      stream a file (stdin if omitted or `-`)
+//   golib-s3 [flags] rm <key>...           delete objects
+//   golib-s3 [flags] latest [-delimiter d] find the latest timestamp-prefixed key
+//
+// The configuration is read from the `AWS_` environment variables (see
+// `.env.example` and `s3.ProfileFromEnv`), or from a profiles file.
+//
+// `get` and `put` stream the content (see `S3.FetchObjectTo` and
+// `S3.CreateObjectFrom`), so objects larger than the available memory
+// can be transferred. `get` verifies the checksum once the content has
+// been written, and fails on a mismatch.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"golib/s3"
+)
+
+var errUsage = errors.New("usage: golib-s3 [-bucket name] [-profiles file -profile name] ls|get|put|rm|latest [args]")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("golib-s3", flag.ContinueOnError)
+	bucket := flags.String("bucket", "", "bucket name, overriding the profile's")
+	profilesFile := flags.String("profiles", "", "JSON profiles file (see `s3.LoadProfiles`)")
+	profileName := flags.String("profile", "", "profile name in the profiles file")
+	envPrefix := flags.String("env-prefix", "AWS_", "prefix of the configuration environment variables")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errUsage
+	}
+
+	var profile s3.Profile
+	var err error
+	if len(*profilesFile) > 0 {
+		profile, err = s3.LoadProfile(*profilesFile, *profileName)
+	} else {
+		profile, err = s3.ProfileFromEnv(*envPrefix)
+	}
+	if err != nil {
+		return err
+	}
+	if len(*bucket) > 0 {
+		profile.Bucket = *bucket
+	}
+	client, err := s3.NewS3FromProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "ls":
+		return list(client, commandArgs, stdout)
+	case "get":
+		return get(client, commandArgs, stdout)
+	case "put":
+		return put(client, commandArgs, stdin, stdout)
+	case "rm":
+		return remove(client, commandArgs, stdout)
+	case "latest":
+		return latest(client, commandArgs, stdout)
+	}
+	return fmt.Errorf("unknown command `%s`\n%v", command, errUsage)
+}
+
+func list(client s3.S3, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("ls", flag.ContinueOnError)
+	long := flags.Bool("l", false, "list objects with their size, date and storage class")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	prefix := flags.Arg(0)
+	if *long {
+		objects, err := client.ListObjectsInfo(prefix)
+		if err != nil {
+			return err
+		}
+		return writeJSON(stdout, objects)
+	}
+	keys, err := client.ListObjects(prefix)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, keys)
+}
+
+func get(client s3.S3, args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	_, err := client.FetchObjectTo(args[0], stdout)
+	return err
+}
+
+func put(client s3.S3, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errUsage
+	}
+	r := stdin
+	if len(args) == 2 && args[1] != "-" {
+		file, err := os.Open(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to open file: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+	n, err := client.CreateObjectFrom(args[0], r)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]interface{}{"key": args[0], "bytes": n})
+}
+
+func remove(client s3.S3, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	deleted := make([]string, 0, len(args))
+	for _, key := range args {
+		if err := client.DeleteObject(key); err != nil {
+			writeJSON(stdout, map[string]interface{}{"deleted": deleted})
+			return err
+		}
+		deleted = append(deleted, key)
+	}
+	return writeJSON(stdout, map[string]interface{}{"deleted": deleted})
+}
+
+func latest(client s3.S3, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("latest", flag.ContinueOnError)
+	delimiter := flags.String("delimiter", "/", "delimiter grouping the timestamp components")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	key, err := client.FindLatestInTimestampPrefixedObjects(*delimiter)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, map[string]interface{}{"key": key})
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newObjectServer returns a minimal S3-like server storing objects by
+// path, enough for `put`, `get` and `rm`. Copies only copy the content.
+func newObjectServer(t *testing.T) *httptest.Server {
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			if source := r.Header.Get("X-Amz-Copy-Source"); len(source) > 0 {
+				source, _ = url.PathUnescape(source)
+				objects[r.URL.Path] = objects["/"+strings.TrimPrefix(source, "/")]
+				w.Write([]byte("<CopyObjectResult></CopyObjectResult>"))
+				return
+			}
+			content, _ := ioutil.ReadAll(r.Body)
+			objects[r.URL.Path] = content
+		case http.MethodGet:
+			content, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(content)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setTestEnv(t *testing.T, endpoint string) {
+	env := map[string]string{
+		"TEST_CLI_BUCKET":           "bucket",
+		"TEST_CLI_ENDPOINT":         endpoint,
+		"TEST_CLI_REGION":           "us-east-1",
+		"TEST_CLI_ACCESS_KEY":       "key",
+		"TEST_CLI_SECRET_KEY":       "secret",
+		"TEST_CLI_FORCE_PATH_STYLE": "true",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+}
+
+func TestPutGetAndRemove(t *testing.T) {
+	server := newObjectServer(t)
+	setTestEnv(t, server.URL)
+
+	var stdout bytes.Buffer
+	err := run([]string{"-env-prefix", "TEST_CLI_", "put", "some/key"}, strings.NewReader("content"), &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), `"bytes": 7`) {
+		t.Errorf("expected put output to report 7 bytes, got `%s`", stdout.String())
+	}
+
+	stdout.Reset()
+	err = run([]string{"-env-prefix", "TEST_CLI_", "get", "some/key"}, nil, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "content" {
+		t.Errorf("expected get output to be `content`, got `%s`", stdout.String())
+	}
+
+	stdout.Reset()
+	err = run([]string{"-env-prefix", "TEST_CLI_", "rm", "some/key"}, nil, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), `"some/key"`) {
+		t.Errorf("expected rm output to list the deleted key, got `%s`", stdout.String())
+	}
+}
+
+// pipe hides the `Seek` method of a reader, like a piped stdin.
+type pipe struct {
+	io.Reader
+}
+
+func TestPutFromPipe(t *testing.T) {
+	server := newObjectServer(t)
+	setTestEnv(t, server.URL)
+
+	var stdout bytes.Buffer
+	err := run([]string{"-env-prefix", "TEST_CLI_", "put", "some/key"}, pipe{strings.NewReader("piped content")}, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), `"bytes": 13`) {
+		t.Errorf("expected put output to report 13 bytes, got `%s`", stdout.String())
+	}
+
+	stdout.Reset()
+	err = run([]string{"-env-prefix", "TEST_CLI_", "get", "some/key"}, nil, &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "piped content" {
+		t.Errorf("expected get output to be `piped content`, got `%s`", stdout.String())
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	setTestEnv(t, "http://localhost")
+	cases := [][]string{
+		{},
+		{"-env-prefix", "TEST_CLI_", "get"},
+		{"-env-prefix", "TEST_CLI_", "rm"},
+		{"-env-prefix", "TEST_CLI_", "nope"},
+	}
+	for _, args := range cases {
+		if err := run(args, nil, ioutil.Discard); err == nil {
+			t.Errorf("expected an error for arguments %v", args)
+		}
+	}
+}
@@ -1,22 +1,120 @@
 package csv
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+
+	"golib"
 )
 
 // ExtractCsvLineItems returns the values of the passed `line`.
 //
 // - `line` is a CSV string
+//
+// Fields are parsed as described in RFC 4180 (see `ParseCsvToRows`).
+// Malformed quoted fields are parsed leniently.
 func ExtractCsvLineItems(line string, sep string) []string {
-	lineItems := strings.Split(line, sep)
-	lineItems[0] = strings.TrimPrefix(lineItems[0], "\"")
-	lineItems[len(lineItems)-1] = strings.TrimRight(lineItems[len(lineItems)-1], "\"\n\r")
+	lineItems, _ := newParser(strings.NewReader(line), sep, '"').readRecord()
+	if lineItems == nil {
+		return []string{""}
+	}
 	return lineItems
 }
 
+// Options configures the parsing of CSV data.
+type Options struct {
+	// Separator separates the fields of a record (defaults to ",").
+	Separator string
+	// Quote encloses fields containing separators, quotes or line breaks
+	// (defaults to `"`).
+	Quote byte
+	// Encoding is the encoding of the data, decoded to UTF-8 (defaults
+	// to UTF-8). A leading byte order mark is always skipped.
+	Encoding Encoding
+	// NoHeader specifies that the data has no header record. Columns
+	// are then named after their index: "col0", "col1"...
+	NoHeader bool
+	// Detect detects the separator, quote, encoding and presence of a
+	// header from the beginning of the data (see `Sniff`). Options set
+	// explicitly take precedence over the detected ones.
+	Detect bool
+	// TimeLayouts are the layouts tried in order to decode `time.Time`
+	// values (defaults to `DefaultTimeLayouts`). Use `EpochMilliseconds`
+	// for values in milliseconds since the epoch.
+	TimeLayouts []string
+	// Ragged is the handling of rows with fewer or more fields than the
+	// header (defaults to `RaggedKeep`).
+	Ragged RaggedPolicy
+	// OverflowColumn is the name of the column collecting the extra
+	// fields with `RaggedOverflow` (defaults to "overflow").
+	OverflowColumn string
+	// MaxRecordSize is the maximum size in bytes of a record, line
+	// breaks included (defaults to no limit). Longer records stop the
+	// parsing with a `*ParseError` wrapping `ErrRecordTooLong`.
+	MaxRecordSize int
+	// HeaderNormalizer maps the header names, e.g. `ParameterizeHeader`
+	// so that "First Name", "firstName" and "FIRST-NAME" all become
+	// "first_name". Headers mapped to the same name stop the parsing
+	// with a `*ParseError` wrapping `ErrDuplicateHeader`.
+	HeaderNormalizer func(header string) string
+	// HeaderAliases maps header names (normalized by `HeaderNormalizer`
+	// if set) to canonical names, so that several spellings resolve to
+	// the same column. Collisions are detected like for
+	// `HeaderNormalizer`.
+	HeaderAliases map[string]string
+}
+
+// ParameterizeHeader normalizes a header name to snake case, with
+// `golib.Parameterize`.
+func ParameterizeHeader(header string) string {
+	return golib.Parameterize(strings.TrimSpace(header), '_')
+}
+
+// RaggedPolicy is the handling of rows with fewer or more fields than
+// the header.
+type RaggedPolicy int
+
+const (
+	// RaggedKeep keeps the rows as they are.
+	RaggedKeep RaggedPolicy = iota
+	// RaggedStrict stops the parsing with a `*ParseError` wrapping
+	// `ErrFieldCount`.
+	RaggedStrict
+	// RaggedPad pads short rows with empty values, and keeps long rows
+	// as they are.
+	RaggedPad
+	// RaggedTruncate pads short rows with empty values, and drops the
+	// extra fields of long rows.
+	RaggedTruncate
+	// RaggedOverflow pads short rows like `RaggedTruncate`, and joins the
+	// extra fields of long rows with the separator in an additional last
+	// column, named after `OverflowColumn`.
+	RaggedOverflow
+)
+
+func (opts Options) separator() string {
+	if len(opts.Separator) == 0 {
+		return ","
+	}
+	return opts.Separator
+}
+
+func (opts Options) quote() byte {
+	if opts.Quote == 0 {
+		return '"'
+	}
+	return opts.Quote
+}
+
+func (opts Options) overflowColumn() string {
+	if len(opts.OverflowColumn) == 0 {
+		return "overflow"
+	}
+	return opts.OverflowColumn
+}
+
 // ParseCsv parses a CSV file, returns a slice of rows with the values
 // for each row and a map mapping the header string to the index of the
 // value in the slice.
@@ -28,66 +126,87 @@ func ExtractCsvLineItems(line string, sep string) []string {
 // result[3][headers["col1"]] // returns the value for "col1" column at row 3
 // ```
 //
-func ParseCsvToRows(filepath string, sep string) (map[string]int, [][]string, error) {
-	headers := make(map[string]int)
-	rows := make([][]string, 0)
-
+// The file is parsed like in `ParseRowsFrom`, with the first of the
+// optional `opts` (the others are ignored). A non-empty `sep` overrides
+// its `Separator`, an empty one keeping it (e.g. to detect it with
+// `Options.Detect`).
+func ParseCsvToRows(filepath string, sep string, opts ...Options) (map[string]int, [][]string, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return headers, rows, fmt.Errorf("failed to open file: %v", err)
+		return make(map[string]int), make([][]string, 0), fmt.Errorf("failed to open file: %v", err)
 	}
+	defer file.Close()
+	return ParseRowsFrom(file, fileOptions(sep, opts))
+}
 
-	rowIdx := 0
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		row := scanner.Text()
-		rowItems := ExtractCsvLineItems(row, sep)
-		if rowIdx == 0 {
-			for i, rowItem := range rowItems {
-				headers[rowItem] = i
-			}
-			rowIdx++
-			continue
-		}
-		rows = append(rows, rowItems)
-		rowIdx++
+// ParseRowsFrom parses CSV data from `r`, returns a slice of rows with
+// the values for each row and a map mapping the header string to the
+// index of the value in the slice.
+//
+// The data is parsed as described in RFC 4180: fields may be enclosed
+// in double quotes to contain separators, line breaks (CRLF or LF) and
+// double quotes (escaped by doubling them). Empty lines are skipped.
+// Rows with fewer or more fields than the header are handled according
+// to `opts.Ragged`. Parsing errors are returned as `*ParseError`.
+func ParseRowsFrom(r io.Reader, opts Options) (map[string]int, [][]string, error) {
+	rows := make([][]string, 0)
+	reader := NewReader(r, opts)
+	headers := reader.HeaderIndex()
+	for reader.Next() {
+		rows = append(rows, reader.Row().Values)
 	}
-	return headers, rows, nil
+	return headers, rows, reader.Err()
 }
 
-// ParseCsvToColumns parses a CSV file, returning a map of string
-// slices representing each column.
+// ParseCsvToColumns parses a CSV file, returning a `Table` which
+// keeps the order of the headers and gives access to each column.
 //
 // Example:
-// result["col1"][3] returns the value for "col1" column at row 3.
-func ParseCsvToColumns(filepath string, sep string) (map[string][]string, error) {
-	columns := make(map[string][]string)
-	var headers []string
-
+// result.Column("col1")[3] returns the value for "col1" column at row 3.
+//
+// The file is parsed like in `ParseRowsFrom`, with the first of the
+// optional `opts` (the others are ignored). A non-empty `sep` overrides
+// its `Separator`, an empty one keeping it (e.g. to detect it with
+// `Options.Detect`).
+func ParseCsvToColumns(filepath string, sep string, opts ...Options) (*Table, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return columns, fmt.Errorf("failed to open file: %v", err)
+		return NewTable(make([]string, 0), make([][]string, 0)), fmt.Errorf("failed to open file: %v", err)
 	}
+	defer file.Close()
+	return ParseColumnsFrom(file, fileOptions(sep, opts))
+}
 
-	rowIdx := 0
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		row := scanner.Text()
-		rowItems := ExtractCsvLineItems(row, sep)
-		if rowIdx == 0 {
-			headers = make([]string, len(rowItems))
-		}
-		for colIdx, rowItem := range rowItems {
-			if rowIdx == 0 {
-				columns[rowItem] = make([]string, 0)
-				headers[colIdx] = rowItem
-			} else {
-				colHeader := headers[colIdx]
-				columns[colHeader] = append(columns[colHeader], rowItem)
-			}
-		}
-		rowIdx++
+// fileOptions returns the options of the functions parsing files: the
+// first of `opts` if any, with its separator set to `sep` unless empty
+// (e.g. to detect it with `Options.Detect`).
+func fileOptions(sep string, opts []Options) Options {
+	var fileOpts Options
+	if len(opts) > 0 {
+		fileOpts = opts[0]
 	}
+	if len(sep) > 0 {
+		fileOpts.Separator = sep
+	}
+	return fileOpts
+}
+
+// ParseColumnsFrom parses CSV data from `r`, returning a `Table` which
+// keeps the order of the headers and gives access to each column.
+//
+// The data is parsed like in `ParseRowsFrom`.
+func ParseColumnsFrom(r io.Reader, opts Options) (*Table, error) {
+	return readTable(NewReader(r, opts))
+}
 
-	return columns, nil
+func readTable(reader *Reader) (*Table, error) {
+	rows := make([][]string, 0)
+	headers := reader.Headers()
+	if headers == nil {
+		headers = make([]string, 0)
+	}
+	for reader.Next() {
+		rows = append(rows, reader.Row().Values)
+	}
+	return NewTable(headers, rows), reader.Err()
 }
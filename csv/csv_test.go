@@ -1,7 +1,10 @@
 package csv_test
 
 import (
-	"fmt"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
 	"testing"
 
 	"golib/csv"
@@ -40,34 +43,129 @@ func TestParseCsvToRows(t *testing.T) {
 }
 
 func TestParseCsvToColumns(t *testing.T) {
-	columns, err := csv.ParseCsvToColumns(CSV_TEST_FILE, CSV_SEP)
-	fmt.Println(columns)
+	table, err := csv.ParseCsvToColumns(CSV_TEST_FILE, CSV_SEP)
 	expectedHeaders := []string{"col0", "col1", "col2"}
 
 	if err != nil {
 		t.Errorf("Failed to parse CSV file: %v", err)
 	}
 
-	if len(columns) != 3 {
-		t.Errorf("Expected 3 columns, got %d", len(columns))
+	if len(table.Headers) != 3 {
+		t.Errorf("Expected 3 columns, got %d", len(table.Headers))
 	}
 
-	for colHeader, col := range columns {
+	for colIdx, colHeader := range table.Headers {
+		col := table.ColumnAt(colIdx)
 		if len(col) != 2 {
 			t.Errorf("Expected column `%s` to have 2 items, got %d (%v)", colHeader, len(col), col)
 		}
-	}
-
-	colIdx := 0
-	for colHeader := range columns {
 		expectedColumn := expectedHeaders[colIdx]
 		if colHeader != expectedColumn {
 			t.Errorf("Expected column `%s`, got `%s`", expectedColumn, colHeader)
 		}
-		colIdx++
 	}
 
-	if columns["col2"][1] != "r1c2" {
-		t.Errorf("Expected item at `col2` and row 1 to be `%s`, got `%s`", "r1c2", columns["col2"][1])
+	if table.Column("col2")[1] != "r1c2" {
+		t.Errorf("Expected item at `col2` and row 1 to be `%s`, got `%s`", "r1c2", table.Column("col2")[1])
+	}
+}
+
+func TestParseRowsFrom(t *testing.T) {
+	data := "a;b\n\"1;2\";3\n"
+	headers, rows, err := csv.ParseRowsFrom(strings.NewReader(data), csv.Options{Separator: ";"})
+	if err != nil {
+		t.Fatalf("Failed to parse CSV data: %v", err)
+	}
+	if len(headers) != 2 || headers["b"] != 1 {
+		t.Errorf("Expected headers `a` and `b`, got %v", headers)
+	}
+	if len(rows) != 1 || rows[0][0] != "1;2" || rows[0][1] != "3" {
+		t.Errorf("Unexpected rows %q", rows)
+	}
+}
+
+func TestParseColumnsFrom(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("a,b\n1,2\n3,4\n"))
+	gz.Close()
+
+	r, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := csv.ParseColumnsFrom(r, csv.Options{})
+	if err != nil {
+		t.Fatalf("Failed to parse CSV data: %v", err)
+	}
+	if column := table.Column("b"); len(column) != 2 || column[1] != "4" {
+		t.Errorf("Unexpected column %v", column)
+	}
+}
+
+func TestParseRaggedRows(t *testing.T) {
+	data := "a,b,c\n1,2\n1,2,3,4,5\n"
+	tests := []struct {
+		policy   csv.RaggedPolicy
+		headers  []string
+		expected [][]string
+	}{
+		{csv.RaggedKeep, []string{"a", "b", "c"}, [][]string{{"1", "2"}, {"1", "2", "3", "4", "5"}}},
+		{csv.RaggedPad, []string{"a", "b", "c"}, [][]string{{"1", "2", ""}, {"1", "2", "3", "4", "5"}}},
+		{csv.RaggedTruncate, []string{"a", "b", "c"}, [][]string{{"1", "2", ""}, {"1", "2", "3"}}},
+		{csv.RaggedOverflow, []string{"a", "b", "c", "extra"}, [][]string{{"1", "2", "", ""}, {"1", "2", "3", "4,5"}}},
+	}
+	for _, test := range tests {
+		opts := csv.Options{Ragged: test.policy, OverflowColumn: "extra"}
+		headers, rows, err := csv.ParseRowsFrom(strings.NewReader(data), opts)
+		if err != nil {
+			t.Fatalf("Failed to parse CSV data with policy %d: %v", test.policy, err)
+		}
+		if len(headers) != len(test.headers) {
+			t.Errorf("Expected headers %v with policy %d, got %v", test.headers, test.policy, headers)
+		}
+		matchRows(t, test.expected, rows)
+
+		table, err := csv.ParseColumnsFrom(strings.NewReader(data), opts)
+		if err != nil {
+			t.Fatalf("Failed to parse CSV data with policy %d: %v", test.policy, err)
+		}
+		matchRows(t, [][]string{test.headers}, [][]string{table.Headers})
+		matchRows(t, test.expected, table.Rows)
+	}
+
+	_, _, err := csv.ParseRowsFrom(strings.NewReader(data), csv.Options{Ragged: csv.RaggedStrict})
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 || !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("Expected a field count error on line 2, got %v", err)
+	}
+
+	// The policies apply to files too
+	path := writeTestFile(t, data)
+	_, rows, err := csv.ParseCsvToRows(path, ",", csv.Options{Ragged: csv.RaggedTruncate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchRows(t, [][]string{{"1", "2", ""}, {"1", "2", "3"}}, rows)
+	if _, err := csv.ParseCsvToColumns(path, ",", csv.Options{Ragged: csv.RaggedStrict}); !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("Expected a field count error, got %v", err)
+	}
+}
+
+func TestParseFilesWithOptions(t *testing.T) {
+	path := writeTestFile(t, "First Name;Age\nJohn;42\n")
+	opts := csv.Options{Detect: true, HeaderNormalizer: csv.ParameterizeHeader}
+	table, err := csv.ParseCsvToColumns(path, "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Value(0, "first_name") != "John" || table.Value(0, "age") != "42" {
+		t.Errorf("Expected the separator to be detected and headers normalized, got %v %q", table.Headers, table.Rows)
+	}
+
+	// The separator argument takes precedence over the options
+	headers, _, err := csv.ParseCsvToRows(path, ",", csv.Options{Separator: ";"})
+	if err != nil || len(headers) != 1 {
+		t.Errorf("Expected the `,` separator to be used, got %v (%v)", headers, err)
 	}
 }
@@ -0,0 +1,306 @@
+package csv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	timeutils "golib/time"
+)
+
+// EpochMilliseconds is a time layout for values expressed as a number
+// of milliseconds since the epoch (see `time.MsToTime`).
+const EpochMilliseconds = "epoch_ms"
+
+// DefaultTimeLayouts are the layouts used to decode `time.Time` fields
+// when no layout is specified in the options.
+var DefaultTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// DecodeError is returned when a value cannot be decoded into a
+// struct field, with the location of the value.
+type DecodeError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("column `%s`: %v", e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d, column `%s`: cannot decode `%s`: %v", e.Line, e.Column, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+var errColumnNotFound = errors.New("column not found in header")
+
+// Decoder decodes the rows of CSV data into structs, one at a time.
+//
+// Struct fields are mapped to columns by their `csv:"name"` tag (or by
+// their name if untagged, and skipped with `csv:"-"`). Supported field
+// types are strings, integers, floats, booleans, `time.Time` (decoded
+// with `Options.TimeLayouts`), types implementing
+// `encoding.TextUnmarshaler` and pointers to these types, which are
+// left nil for empty values so they can represent optional values.
+//
+// Columns mapped to non-pointer fields must be present in the header.
+type Decoder struct {
+	r       *Reader
+	layouts []string
+}
+
+// NewDecoder returns a decoder of the CSV data from `r`, parsed like
+// in `ParseRowsFrom`.
+func NewDecoder(r io.Reader, opts Options) *Decoder {
+	layouts := opts.TimeLayouts
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	return &Decoder{
+		r:       NewReader(r, opts),
+		layouts: layouts,
+	}
+}
+
+// Decode decodes the next row into the struct pointed to by `v`,
+// returning `io.EOF` when there are no more rows.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", v)
+	}
+	if d.r.Headers() == nil {
+		// Empty data
+		if err := d.r.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	fields, err := d.fieldsFor(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	if !d.r.Next() {
+		if err := d.r.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return d.decodeRow(d.r.Row(), fields, rv.Elem())
+}
+
+// DecodeAll decodes all the remaining rows into the slice of structs
+// (or of pointers to structs) pointed to by `v`.
+func (d *Decoder) DecodeAll(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice, got %T", v)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("expected a slice of structs, got %T", v)
+	}
+
+	for {
+		elem := reflect.New(structType)
+		err := d.Decode(elem.Interface())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+}
+
+// Unmarshal decodes all the rows of the CSV data from `r` (with the
+// default options) into the slice of structs pointed to by `v`. See
+// `Decoder` for the mapping of columns to struct fields.
+func Unmarshal(r io.Reader, v interface{}) error {
+	return NewDecoder(r, Options{}).DecodeAll(v)
+}
+
+// structField maps a struct field to a column.
+type structField struct {
+	index  []int
+	column string
+}
+
+var structFieldsCache sync.Map
+
+// structFields returns the fields of the struct type `t` with the
+// columns they map to.
+func structFields(t reflect.Type) []structField {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]structField)
+	}
+	fields := make([]structField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 {
+			// Unexported field
+			continue
+		}
+		column := f.Name
+		if tag, ok := f.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			if name := strings.Split(tag, ",")[0]; len(name) > 0 {
+				column = name
+			}
+		}
+		fields = append(fields, structField{index: f.Index, column: column})
+	}
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+// fieldsFor returns the fields of `t`, checking the columns of the
+// required ones are present in the header.
+func (d *Decoder) fieldsFor(t reflect.Type) ([]structField, error) {
+	fields := structFields(t)
+	index := d.r.HeaderIndex()
+	for _, f := range fields {
+		if _, ok := index[f.column]; !ok && t.FieldByIndex(f.index).Type.Kind() != reflect.Ptr {
+			return nil, &DecodeError{Column: f.column, Err: errColumnNotFound}
+		}
+	}
+	return fields, nil
+}
+
+func (d *Decoder) decodeRow(row Row, fields []structField, v reflect.Value) error {
+	for _, f := range fields {
+		value, ok := row.Lookup(f.column)
+		if !ok {
+			continue
+		}
+		if err := d.decodeValue(value, v.FieldByIndex(f.index)); err != nil {
+			return &DecodeError{Line: row.Line, Column: f.column, Value: value, Err: err}
+		}
+	}
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+func (d *Decoder) decodeValue(value string, field reflect.Value) error {
+	if field.Kind() == reflect.Ptr {
+		if len(value) == 0 {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		ptr := reflect.New(field.Type().Elem())
+		if err := d.decodeValue(value, ptr.Elem()); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.Type() == timeType {
+		if len(value) == 0 {
+			field.Set(reflect.Zero(timeType))
+			return nil
+		}
+		t, err := parseTime(value, d.layouts)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+	// Checked after `time.Time`, which implements it with a single layout.
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if field.Kind() != reflect.String {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseTime(value string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if layout == EpochMilliseconds {
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			return timeutils.MsToTime(ms)
+		}
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no matching time layout in %q", layouts)
+}
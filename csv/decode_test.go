@@ -0,0 +1,99 @@
+package csv_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"golib/csv"
+)
+
+type decodedRecord struct {
+	Name     string     `csv:"name"`
+	Age      int        `csv:"age"`
+	Score    float64    `csv:"score"`
+	Active   bool       `csv:"active"`
+	Created  time.Time  `csv:"created"`
+	Nickname *string    `csv:"nickname"`
+	Deleted  *time.Time `csv:"deleted_at"`
+	Ignored  string     `csv:"-"`
+	Country  string
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := "name,age,score,active,created,nickname,Country\n" +
+		"John,42,1.5,true,2021-04-30,Johnny,FR\n" +
+		"Jane, 7 ,,no,2021-04-30T10:00:00Z,,\n"
+	var records []decodedRecord
+	if err := csv.Unmarshal(strings.NewReader(data), &records); err != nil {
+		t.Fatalf("Failed to unmarshal CSV data: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+
+	john := records[0]
+	if john.Name != "John" || john.Age != 42 || john.Score != 1.5 || !john.Active || john.Country != "FR" {
+		t.Errorf("Unexpected record %+v", john)
+	}
+	if john.Nickname == nil || *john.Nickname != "Johnny" {
+		t.Errorf("Expected nickname `Johnny`, got %v", john.Nickname)
+	}
+	if !john.Created.Equal(time.Date(2021, time.April, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected creation time %v", john.Created)
+	}
+
+	jane := records[1]
+	if jane.Age != 7 || jane.Score != 0 || jane.Active || jane.Nickname != nil || jane.Deleted != nil {
+		t.Errorf("Unexpected record %+v", jane)
+	}
+	if !jane.Created.Equal(time.Date(2021, time.April, 30, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected creation time %v", jane.Created)
+	}
+}
+
+func TestDecoderWithEpochMilliseconds(t *testing.T) {
+	type event struct {
+		At *time.Time `csv:"at"`
+	}
+	data := "at\n1522445543669\n"
+	d := csv.NewDecoder(strings.NewReader(data), csv.Options{TimeLayouts: []string{csv.EpochMilliseconds}})
+
+	var e event
+	if err := d.Decode(&e); err != nil {
+		t.Fatalf("Failed to decode CSV data: %v", err)
+	}
+	if e.At == nil || e.At.UnixNano() != 1522445543669000000 {
+		t.Errorf("Unexpected time %v", e.At)
+	}
+	if err := d.Decode(&e); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the data, got %v", err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var records []decodedRecord
+	data := "name,age,score,active,created,Country\nJohn,42,1.5,true,2021-04-30,FR\nJane,old,1,true,2021-04-30,FR\n"
+	err := csv.Unmarshal(strings.NewReader(data), &records)
+	var decodeErr *csv.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected a decode error, got %v", err)
+	}
+	if decodeErr.Line != 3 || decodeErr.Column != "age" || decodeErr.Value != "old" {
+		t.Errorf("Unexpected error location %+v", decodeErr)
+	}
+
+	err = csv.Unmarshal(strings.NewReader("name\nJohn\n"), &records)
+	if !errors.As(err, &decodeErr) || decodeErr.Column != "age" {
+		t.Errorf("Expected an error for the missing `age` column, got %v", err)
+	}
+
+	if err := csv.Unmarshal(strings.NewReader(""), &records); err != nil {
+		t.Errorf("Expected no error for empty data, got %v", err)
+	}
+	if err := csv.Unmarshal(strings.NewReader(data), records); err == nil {
+		t.Errorf("Expected an error when not passing a pointer")
+	}
+}
@@ -0,0 +1,166 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding of CSV data, decoded to UTF-8 when
+// parsing.
+type Encoding string
+
+const (
+	// EncodingUTF8 is the default encoding.
+	EncodingUTF8 Encoding = "utf-8"
+	// EncodingUTF16LE is UTF-16, little-endian.
+	EncodingUTF16LE Encoding = "utf-16le"
+	// EncodingUTF16BE is UTF-16, big-endian.
+	EncodingUTF16BE Encoding = "utf-16be"
+	// EncodingWindows1252 is the Windows Western European code page,
+	// a superset of ISO-8859-1.
+	EncodingWindows1252 Encoding = "windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// windows1252 maps the bytes 0x80 to 0x9f of Windows-1252 to runes.
+// The other bytes map to the rune with the same value, like ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// decodeReader returns a reader decoding the data from `br` with the
+// specified encoding to UTF-8. The byte order mark, if any, is skipped.
+func decodeReader(br *bufio.Reader, encoding Encoding) io.Reader {
+	switch encoding {
+	case EncodingUTF16LE:
+		skipPrefix(br, bomUTF16LE)
+		return &utf16Reader{r: br, bigEndian: false}
+	case EncodingUTF16BE:
+		skipPrefix(br, bomUTF16BE)
+		return &utf16Reader{r: br, bigEndian: true}
+	case EncodingWindows1252:
+		return &windows1252Reader{r: br}
+	}
+	skipPrefix(br, bomUTF8)
+	return br
+}
+
+func skipPrefix(br *bufio.Reader, prefix []byte) {
+	if start, err := br.Peek(len(prefix)); err == nil && bytes.Equal(start, prefix) {
+		br.Discard(len(prefix))
+	}
+}
+
+// decodeBytes decodes `data` with the specified encoding to UTF-8.
+func decodeBytes(data []byte, encoding Encoding) []byte {
+	var out bytes.Buffer
+	out.ReadFrom(decodeReader(bufio.NewReader(bytes.NewReader(data)), encoding))
+	return out.Bytes()
+}
+
+// utf16Reader decodes UTF-16 data to UTF-8.
+type utf16Reader struct {
+	r         *bufio.Reader
+	bigEndian bool
+	pending   []byte
+}
+
+func (u *utf16Reader) readUnit() (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	if u.bigEndian {
+		return uint16(b[0])<<8 | uint16(b[1]), nil
+	}
+	return uint16(b[1])<<8 | uint16(b[0]), nil
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(u.pending) > 0 {
+			c := copy(p[n:], u.pending)
+			u.pending = u.pending[c:]
+			n += c
+			continue
+		}
+		unit, err := u.readUnit()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		r := rune(unit)
+		if utf16.IsSurrogate(r) {
+			next, err := u.readUnit()
+			if err != nil {
+				r = utf8.RuneError
+			} else {
+				r = utf16.DecodeRune(r, rune(next))
+			}
+		}
+		var buf [utf8.UTFMax]byte
+		u.pending = buf[:utf8.EncodeRune(buf[:], r)]
+		if n > 0 && u.r.Buffered() == 0 {
+			// Return what has been decoded instead of blocking
+			c := copy(p[n:], u.pending)
+			u.pending = u.pending[c:]
+			return n + c, nil
+		}
+	}
+	return n, nil
+}
+
+// windows1252Reader decodes Windows-1252 data to UTF-8.
+type windows1252Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func (w *windows1252Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(w.pending) > 0 {
+			c := copy(p[n:], w.pending)
+			w.pending = w.pending[c:]
+			n += c
+			continue
+		}
+		if n > 0 && w.r.Buffered() == 0 {
+			return n, nil
+		}
+		b, err := w.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b < utf8.RuneSelf {
+			p[n] = b
+			n++
+			continue
+		}
+		r := rune(b)
+		if b >= 0x80 && b <= 0x9f {
+			r = windows1252[b-0x80]
+		}
+		var buf [utf8.UTFMax]byte
+		w.pending = buf[:utf8.EncodeRune(buf[:], r)]
+	}
+	return n, nil
+}
@@ -0,0 +1,171 @@
+package csv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CellError is reported for a table cell which cannot be converted to
+// the requested type.
+type CellError struct {
+	Row    int // index of the row in the table
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *CellError) Error() string {
+	return fmt.Sprintf("row %d, column `%s`: cannot convert `%s`: %v", e.Row, e.Column, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
+// CellErrors are the errors of all the cells of a column which cannot be
+// converted to the requested type.
+type CellErrors []*CellError
+
+func (e CellErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e[0], len(e)-1)
+}
+
+// InferType infers the type of the first column named `name` from its
+// non-empty values: `TypeInt`, `TypeFloat`, `TypeBool` or `TypeTime`
+// (with `DefaultTimeLayouts`) if they all are of that type, and
+// `TypeString` otherwise or if they are all empty.
+func (t *Table) InferType(name string) ColumnType {
+	i := t.Index(name)
+	if i < 0 {
+		return TypeString
+	}
+	return inferType(t.ColumnAt(i))
+}
+
+// InferTypes returns the inferred type of each column, in the order of
+// the headers (see `InferType`).
+func (t *Table) InferTypes() []ColumnType {
+	types := make([]ColumnType, len(t.Headers))
+	for i := range t.Headers {
+		types[i] = inferType(t.ColumnAt(i))
+	}
+	return types
+}
+
+func inferType(values []string) ColumnType {
+	candidates := []ColumnType{TypeInt, TypeFloat, TypeBool, TypeTime}
+	empty := true
+	for _, value := range values {
+		if len(strings.TrimSpace(value)) == 0 {
+			continue
+		}
+		empty = false
+		remaining := candidates[:0]
+		for _, candidate := range candidates {
+			if (ColumnSchema{Type: candidate}).validate(value) == nil {
+				remaining = append(remaining, candidate)
+			}
+		}
+		candidates = remaining
+		if len(candidates) == 0 {
+			return TypeString
+		}
+	}
+	if empty {
+		return TypeString
+	}
+	return candidates[0]
+}
+
+// Ints returns the values of the first column named `name` as integers.
+// Empty values are returned as nil. Values which cannot be converted are
+// also returned as nil, and reported in the returned `CellErrors`.
+func (t *Table) Ints(name string) ([]*int64, error) {
+	values := make([]*int64, t.Len())
+	err := t.convert(name, func(row int, value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			values[row] = &n
+		}
+		return err
+	})
+	return values, err
+}
+
+// Floats returns the values of the first column named `name` as floats,
+// with empty and invalid values handled like in `Ints`.
+func (t *Table) Floats(name string) ([]*float64, error) {
+	values := make([]*float64, t.Len())
+	err := t.convert(name, func(row int, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			values[row] = &f
+		}
+		return err
+	})
+	return values, err
+}
+
+// Bools returns the values of the first column named `name` as
+// booleans (true/false, yes/no, 1/0...), with empty and invalid values
+// handled like in `Ints`.
+func (t *Table) Bools(name string) ([]*bool, error) {
+	values := make([]*bool, t.Len())
+	err := t.convert(name, func(row int, value string) error {
+		b, err := parseBool(value)
+		if err == nil {
+			values[row] = &b
+		}
+		return err
+	})
+	return values, err
+}
+
+// Times returns the values of the first column named `name` as times
+// parsed with `layout` (with `DefaultTimeLayouts` if empty, use
+// `EpochMilliseconds` for milliseconds since the epoch), with empty and
+// invalid values handled like in `Ints`.
+func (t *Table) Times(name string, layout string) ([]*time.Time, error) {
+	layouts := DefaultTimeLayouts
+	if len(layout) > 0 {
+		layouts = []string{layout}
+	}
+	values := make([]*time.Time, t.Len())
+	err := t.convert(name, func(row int, value string) error {
+		parsed, err := parseTime(value, layouts)
+		if err == nil {
+			values[row] = &parsed
+		}
+		return err
+	})
+	return values, err
+}
+
+// convert calls `convertValue` with each non-empty, trimmed value of the
+// first column named `name`, collecting the errors.
+func (t *Table) convert(name string, convertValue func(row int, value string) error) error {
+	i := t.Index(name)
+	if i < 0 {
+		return &DecodeError{Column: name, Err: errColumnNotFound}
+	}
+	cellErrors := make(CellErrors, 0)
+	for row, raw := range t.ColumnAt(i) {
+		value := strings.TrimSpace(raw)
+		if len(value) == 0 {
+			continue
+		}
+		if err := convertValue(row, value); err != nil {
+			cellErrors = append(cellErrors, &CellError{Row: row, Column: name, Value: raw, Err: err})
+		}
+	}
+	if len(cellErrors) > 0 {
+		return cellErrors
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package csv_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golib/csv"
+)
+
+func TestInferTypes(t *testing.T) {
+	data := "id,price,active,created,name,empty\n" +
+		"1,9.99,yes,2021-04-30,John,\n" +
+		"2,10,no,2021-05-01T10:00:00Z,Jane,\n" +
+		",,,,,\n"
+	table, err := csv.ParseColumnsFrom(strings.NewReader(data), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []csv.ColumnType{csv.TypeInt, csv.TypeFloat, csv.TypeBool, csv.TypeTime, csv.TypeString, csv.TypeString}
+	for i, columnType := range table.InferTypes() {
+		if columnType != expected[i] {
+			t.Errorf("Expected column `%s` to be %s, got %s", table.Headers[i], expected[i], columnType)
+		}
+	}
+	if columnType := table.InferType("missing"); columnType != csv.TypeString {
+		t.Errorf("Expected a missing column to be a string, got %s", columnType)
+	}
+}
+
+func TestTypedColumns(t *testing.T) {
+	data := "id,price,created\n" +
+		"1,9.99,2021-04-30\n" +
+		",,\n" +
+		"x,1e3,30/04/2021\n"
+	table, err := csv.ParseColumnsFrom(strings.NewReader(data), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := table.Ints("id")
+	var cellErrors csv.CellErrors
+	if !errors.As(err, &cellErrors) || len(cellErrors) != 1 || cellErrors[0].Row != 2 || cellErrors[0].Value != "x" {
+		t.Errorf("Expected an error for the cell at row 2, got %v", err)
+	}
+	if len(ids) != 3 || ids[0] == nil || *ids[0] != 1 || ids[1] != nil || ids[2] != nil {
+		t.Errorf("Unexpected ids %v", ids)
+	}
+
+	prices, err := table.Floats("price")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *prices[0] != 9.99 || prices[1] != nil || *prices[2] != 1000 {
+		t.Errorf("Unexpected prices %v", prices)
+	}
+
+	times, err := table.Times("created", "02/01/2006")
+	if !errors.As(err, &cellErrors) || len(cellErrors) != 1 || cellErrors[0].Row != 0 {
+		t.Errorf("Expected an error for the cell at row 0, got %v", err)
+	}
+	if times[0] != nil || times[1] != nil || !times[2].Equal(time.Date(2021, 4, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected times %v", times)
+	}
+
+	if _, err := table.Ints("missing"); err == nil {
+		t.Error("Expected an error for a missing column")
+	}
+}
@@ -0,0 +1,211 @@
+package csv
+
+import (
+	"fmt"
+)
+
+// JoinType is the type of a `Join`.
+type JoinType int
+
+const (
+	// InnerJoin keeps the rows whose keys are in both tables.
+	InnerJoin JoinType = iota
+	// LeftJoin keeps all the rows of the left table, with empty values
+	// for the right columns of the rows not in the right table.
+	LeftJoin
+	// FullOuterJoin keeps all the rows of both tables, with empty values
+	// for the columns of the table missing the row.
+	FullOuterJoin
+)
+
+// Join returns a table joining the rows of `left` and `right` having
+// the same values in the `keys` columns. Its columns are the key
+// columns, then the other columns of `left` and of `right`. Columns of
+// `right` named like a column of `left` are suffixed with "_right".
+//
+// Rows come in the order of `left`, followed for a `FullOuterJoin` by
+// the rows only in `right`. A row matching several rows of the other
+// table is repeated for each of them.
+func Join(left, right *Table, keys []string, joinType JoinType) (*Table, error) {
+	leftKeys, err := left.columnIndexes(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join left table, %v", err)
+	}
+	rightKeys, err := right.columnIndexes(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join right table, %v", err)
+	}
+	leftOthers := otherColumns(left, leftKeys)
+	rightOthers := otherColumns(right, rightKeys)
+
+	headers := append([]string(nil), keys...)
+	for _, i := range leftOthers {
+		headers = append(headers, left.Headers[i])
+	}
+	for _, i := range rightOthers {
+		name := right.Headers[i]
+		if left.Index(name) >= 0 {
+			name += "_right"
+		}
+		headers = append(headers, name)
+	}
+
+	rightRows := make(map[string][]int)
+	for rowIdx, row := range right.Rows {
+		id := rowKey(pick(row, rightKeys))
+		rightRows[id] = append(rightRows[id], rowIdx)
+	}
+
+	rows := make([][]string, 0)
+	matched := make([]bool, len(right.Rows))
+	emptyRight := make([]string, len(rightOthers))
+	for _, row := range left.Rows {
+		keyValues := pick(row, leftKeys)
+		joined := append(keyValues, pick(row, leftOthers)...)
+		matches := rightRows[rowKey(keyValues)]
+		if len(matches) == 0 {
+			if joinType != InnerJoin {
+				rows = append(rows, append(joined, emptyRight...))
+			}
+			continue
+		}
+		for _, rowIdx := range matches {
+			matched[rowIdx] = true
+			values := append([]string(nil), joined...)
+			rows = append(rows, append(values, pick(right.Rows[rowIdx], rightOthers)...))
+		}
+	}
+
+	if joinType == FullOuterJoin {
+		emptyLeft := make([]string, len(leftOthers))
+		for rowIdx, row := range right.Rows {
+			if matched[rowIdx] {
+				continue
+			}
+			values := append(pick(row, rightKeys), emptyLeft...)
+			rows = append(rows, append(values, pick(row, rightOthers)...))
+		}
+	}
+	return NewTable(headers, rows), nil
+}
+
+// otherColumns returns the indexes of the columns of `t` not in `keys`.
+func otherColumns(t *Table, keys []int) []int {
+	others := make([]int, 0, len(t.Headers))
+	for i := range t.Headers {
+		isKey := false
+		for _, key := range keys {
+			isKey = isKey || i == key
+		}
+		if !isKey {
+			others = append(others, i)
+		}
+	}
+	return others
+}
+
+// CellChange is a value changed between two versions of a row.
+type CellChange struct {
+	Column string
+	Old    string
+	New    string
+}
+
+// RowChange is a row whose values changed between two versions of a
+// table.
+type RowChange struct {
+	Key     string
+	Old     []string
+	New     []string
+	Changes []CellChange
+}
+
+// TableDiff is the difference between two versions of a table, as
+// returned by `Diff`.
+type TableDiff struct {
+	// Added are the rows only in the new table, in its order.
+	Added [][]string
+	// Removed are the rows only in the old table, in its order.
+	Removed [][]string
+	// Changed are the rows in both tables with different values, in the
+	// order of the new table.
+	Changed []RowChange
+}
+
+// Diff returns the rows added, removed and changed between the `oldTable`
+// and `newTable` versions of a table, matched by their value of the `key`
+// column, which must be unique in each table.
+//
+// Values are compared by column name: a column only in one of the
+// tables is compared to empty values.
+func Diff(oldTable, newTable *Table, key string) (*TableDiff, error) {
+	oldRows, err := rowsByKey(oldTable, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff old table, %v", err)
+	}
+	newRows, err := rowsByKey(newTable, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff new table, %v", err)
+	}
+
+	columns := append([]string(nil), newTable.Headers...)
+	for _, header := range oldTable.Headers {
+		if newTable.Index(header) < 0 {
+			columns = append(columns, header)
+		}
+	}
+
+	diff := &TableDiff{
+		Added:   make([][]string, 0),
+		Removed: make([][]string, 0),
+		Changed: make([]RowChange, 0),
+	}
+	keyIdx := newTable.Index(key)
+	for rowIdx, row := range newTable.Rows {
+		oldIdx, ok := oldRows[cell(row, keyIdx)]
+		if !ok {
+			diff.Added = append(diff.Added, row)
+			continue
+		}
+		changes := make([]CellChange, 0)
+		for _, column := range columns {
+			oldValue, newValue := oldTable.Value(oldIdx, column), newTable.Value(rowIdx, column)
+			if oldValue != newValue {
+				changes = append(changes, CellChange{Column: column, Old: oldValue, New: newValue})
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, RowChange{
+				Key:     cell(row, keyIdx),
+				Old:     oldTable.Rows[oldIdx],
+				New:     row,
+				Changes: changes,
+			})
+		}
+	}
+	keyIdx = oldTable.Index(key)
+	for _, row := range oldTable.Rows {
+		if _, ok := newRows[cell(row, keyIdx)]; !ok {
+			diff.Removed = append(diff.Removed, row)
+		}
+	}
+	return diff, nil
+}
+
+// rowsByKey maps the values of the `key` column to the index of their
+// row, checking they are unique.
+func rowsByKey(t *Table, key string) (map[string]int, error) {
+	keyIdx := t.Index(key)
+	if keyIdx < 0 {
+		return nil, &DecodeError{Column: key, Err: errColumnNotFound}
+	}
+	rows := make(map[string]int)
+	for rowIdx, row := range t.Rows {
+		value := cell(row, keyIdx)
+		if previous, ok := rows[value]; ok {
+			return nil, fmt.Errorf("duplicate key `%s` in rows %d and %d", value, previous, rowIdx)
+		}
+		rows[value] = rowIdx
+	}
+	return rows, nil
+}
@@ -0,0 +1,83 @@
+package csv_test
+
+import (
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+func parseTable(t *testing.T, data string) *csv.Table {
+	t.Helper()
+	table, err := csv.ParseColumnsFrom(strings.NewReader(data), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestJoin(t *testing.T) {
+	left := parseTable(t, "id,region,amount\n1,eu,10\n2,us,20\n3,eu,30\n")
+	right := parseTable(t, "region,id,amount,status\neu,1,10,ok\neu,3,31,ko\nus,4,40,ok\neu,3,32,ok\n")
+
+	tests := []struct {
+		joinType csv.JoinType
+		expected [][]string
+	}{
+		{csv.InnerJoin, [][]string{
+			{"1", "eu", "10", "10", "ok"},
+			{"3", "eu", "30", "31", "ko"},
+			{"3", "eu", "30", "32", "ok"},
+		}},
+		{csv.LeftJoin, [][]string{
+			{"1", "eu", "10", "10", "ok"},
+			{"2", "us", "20", "", ""},
+			{"3", "eu", "30", "31", "ko"},
+			{"3", "eu", "30", "32", "ok"},
+		}},
+		{csv.FullOuterJoin, [][]string{
+			{"1", "eu", "10", "10", "ok"},
+			{"2", "us", "20", "", ""},
+			{"3", "eu", "30", "31", "ko"},
+			{"3", "eu", "30", "32", "ok"},
+			{"4", "us", "", "40", "ok"},
+		}},
+	}
+	for _, test := range tests {
+		table, err := csv.Join(left, right, []string{"id", "region"}, test.joinType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matchRows(t, [][]string{{"id", "region", "amount", "amount_right", "status"}}, [][]string{table.Headers})
+		matchRows(t, test.expected, table.Rows)
+	}
+
+	if _, err := csv.Join(left, right, []string{"status"}, csv.InnerJoin); err == nil {
+		t.Error("Expected an error for a key column missing from the left table")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldTable := parseTable(t, "id,name,amount\n1,John,10\n2,Jane,20\n3,Jim,30\n")
+	newTable := parseTable(t, "id,amount,name,status\n3,30,Jim,\n1,15,John,paid\n4,40,Joe,\n")
+
+	diff, err := csv.Diff(oldTable, newTable, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchRows(t, [][]string{{"4", "40", "Joe", ""}}, diff.Added)
+	matchRows(t, [][]string{{"2", "Jane", "20"}}, diff.Removed)
+	if len(diff.Changed) != 1 || diff.Changed[0].Key != "1" {
+		t.Fatalf("Expected row `1` to be changed, got %+v", diff.Changed)
+	}
+	expected := []csv.CellChange{{Column: "amount", Old: "10", New: "15"}, {Column: "status", Old: "", New: "paid"}}
+	changes := diff.Changed[0].Changes
+	if len(changes) != len(expected) || changes[0] != expected[0] || changes[1] != expected[1] {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+
+	duplicated := parseTable(t, "id,name\n1,John\n1,Jane\n")
+	if _, err := csv.Diff(oldTable, duplicated, "id"); err == nil {
+		t.Error("Expected an error for duplicate keys")
+	}
+}
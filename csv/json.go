@@ -0,0 +1,354 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONFormat is a JSON representation of a table.
+type JSONFormat string
+
+const (
+	// JSONLines is an object per row, keyed by header, on its own line.
+	JSONLines JSONFormat = "jsonl"
+	// JSONArray is an array of objects, one per row, keyed by header.
+	JSONArray JSONFormat = "array"
+	// JSONColumns is an object mapping each header to the array of the
+	// column's values.
+	JSONColumns JSONFormat = "columns"
+)
+
+// JSONOptions configures the conversion of a table to JSON.
+type JSONOptions struct {
+	Format JSONFormat
+	// Typed writes the values of the columns inferred as numbers or
+	// booleans (see `Table.InferType`) as JSON numbers or booleans, and
+	// empty values as null. Otherwise all values are written as strings,
+	// as are numbers when some of the column's are not valid JSON numbers
+	// (e.g. `007`).
+	Typed bool
+}
+
+// WriteJSON writes the table as JSON to `w`, with the keys in the order
+// of the headers. Repeated header names are written once, with the
+// values of the first column with that name.
+func (t *Table) WriteJSON(w io.Writer, opts JSONOptions) error {
+	bw := bufio.NewWriter(w)
+	columns := t.jsonColumns(opts.Typed)
+
+	switch opts.Format {
+	case JSONLines, JSONArray:
+		if opts.Format == JSONArray {
+			bw.WriteString("[")
+		}
+		for rowIdx, row := range t.Rows {
+			if opts.Format == JSONArray && rowIdx > 0 {
+				bw.WriteString(",")
+			}
+			bw.WriteString("{")
+			for i, col := range columns {
+				if i > 0 {
+					bw.WriteString(",")
+				}
+				bw.Write(col.key)
+				bw.WriteString(":")
+				bw.Write(col.value(cell(row, col.index)))
+			}
+			bw.WriteString("}")
+			if opts.Format == JSONLines {
+				bw.WriteString("\n")
+			}
+		}
+		if opts.Format == JSONArray {
+			bw.WriteString("]\n")
+		}
+	case JSONColumns:
+		bw.WriteString("{")
+		for i, col := range columns {
+			if i > 0 {
+				bw.WriteString(",")
+			}
+			bw.Write(col.key)
+			bw.WriteString(":[")
+			for rowIdx, row := range t.Rows {
+				if rowIdx > 0 {
+					bw.WriteString(",")
+				}
+				bw.Write(col.value(cell(row, col.index)))
+			}
+			bw.WriteString("]")
+		}
+		bw.WriteString("}\n")
+	default:
+		return fmt.Errorf("unsupported JSON format `%s`", opts.Format)
+	}
+	return bw.Flush()
+}
+
+// jsonColumn is a column written as JSON.
+type jsonColumn struct {
+	index     int
+	key       []byte
+	valueType ColumnType
+}
+
+func (t *Table) jsonColumns(typed bool) []jsonColumn {
+	columns := make([]jsonColumn, 0, len(t.Headers))
+	for i, header := range t.Headers {
+		if t.Index(header) != i {
+			continue
+		}
+		key, _ := json.Marshal(header)
+		col := jsonColumn{index: i, key: key, valueType: TypeString}
+		if typed {
+			col.valueType = jsonType(t.ColumnAt(i))
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// jsonType returns the inferred type of a column's values, or
+// `TypeString` for numbers which are not all valid JSON numbers (such as
+// `007`, `+1` or `.5`), so the column's values have the same JSON type.
+func jsonType(values []string) ColumnType {
+	valueType := inferType(values)
+	if valueType != TypeInt && valueType != TypeFloat {
+		return valueType
+	}
+	for _, value := range values {
+		trimmed := bytes.TrimSpace([]byte(value))
+		if len(trimmed) > 0 && !json.Valid(trimmed) {
+			return TypeString
+		}
+	}
+	return valueType
+}
+
+func (col jsonColumn) value(value string) []byte {
+	if col.valueType != TypeString {
+		trimmed := bytes.TrimSpace([]byte(value))
+		switch {
+		case len(trimmed) == 0:
+			return []byte("null")
+		case col.valueType == TypeInt || col.valueType == TypeFloat:
+			return trimmed
+		case col.valueType == TypeBool:
+			if b, err := parseBool(string(trimmed)); err == nil {
+				encoded, _ := json.Marshal(b)
+				return encoded
+			}
+		}
+	}
+	encoded, _ := json.Marshal(value)
+	return encoded
+}
+
+// ReadJSON reads a table from JSON data in the specified format (see
+// `WriteJSON`).
+//
+// Nested objects are flattened, their keys being joined with dots: the
+// row `{"a": {"b": 1}}` has the column "a.b". Headers are in the order
+// of their first appearance, and values missing from a row are empty.
+// Strings are unquoted, null values are empty and other values (numbers,
+// booleans and arrays) are kept as JSON. Data following the array or
+// the object of the `JSONArray` and `JSONColumns` formats is an error.
+func ReadJSON(r io.Reader, format JSONFormat) (*Table, error) {
+	builder := newTableBuilder()
+	dec := json.NewDecoder(r)
+
+	switch format {
+	case JSONLines:
+		for {
+			var raw json.RawMessage
+			err := dec.Decode(&raw)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode JSON row %d, %v", len(builder.rows), err)
+			}
+			if err := builder.addObject(raw); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON row %d, %v", len(builder.rows), err)
+			}
+		}
+	case JSONArray:
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON row %d, %v", len(builder.rows), err)
+			}
+			if err := builder.addObject(raw); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON row %d, %v", len(builder.rows), err)
+			}
+		}
+		if err := expectEnd(dec, ']'); err != nil {
+			return nil, err
+		}
+	case JSONColumns:
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode JSON column, %v", err)
+			}
+			var values []json.RawMessage
+			if err := dec.Decode(&values); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON column `%v`, %v", key, err)
+			}
+			builder.addColumn(key.(string), values)
+		}
+		if err := expectEnd(dec, '}'); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JSON format `%s`", format)
+	}
+	return builder.table(), nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode JSON, %v", err)
+	}
+	if token != delim {
+		return fmt.Errorf("failed to decode JSON, expected `%v`, got `%v`", delim, token)
+	}
+	return nil
+}
+
+// expectEnd reads the `delim` closing the data, which must be followed
+// by nothing but whitespace.
+func expectEnd(dec *json.Decoder, delim json.Delim) error {
+	if err := expectDelim(dec, delim); err != nil {
+		return err
+	}
+	if token, err := dec.Token(); err != io.EOF {
+		if err != nil {
+			return fmt.Errorf("failed to decode JSON, %v", err)
+		}
+		return fmt.Errorf("failed to decode JSON, unexpected `%v` after `%v`", token, delim)
+	}
+	return nil
+}
+
+// tableBuilder builds a table from rows whose columns are not known in
+// advance.
+type tableBuilder struct {
+	headers []string
+	index   map[string]int
+	rows    [][]string
+}
+
+func newTableBuilder() *tableBuilder {
+	return &tableBuilder{
+		headers: make([]string, 0),
+		index:   make(map[string]int),
+		rows:    make([][]string, 0),
+	}
+}
+
+func (b *tableBuilder) columnIndex(name string) int {
+	i, ok := b.index[name]
+	if !ok {
+		i = len(b.headers)
+		b.index[name] = i
+		b.headers = append(b.headers, name)
+	}
+	return i
+}
+
+func (b *tableBuilder) set(rowIdx int, name string, value string) {
+	i := b.columnIndex(name)
+	row := b.rows[rowIdx]
+	for len(row) <= i {
+		row = append(row, "")
+	}
+	row[i] = value
+	b.rows[rowIdx] = row
+}
+
+func (b *tableBuilder) addObject(raw json.RawMessage) error {
+	b.rows = append(b.rows, make([]string, 0, len(b.headers)))
+	rowIdx := len(b.rows) - 1
+	return flattenObject(raw, "", func(name, value string) {
+		b.set(rowIdx, name, value)
+	})
+}
+
+func (b *tableBuilder) addColumn(name string, values []json.RawMessage) {
+	for len(b.rows) < len(values) {
+		b.rows = append(b.rows, make([]string, 0, len(b.headers)))
+	}
+	b.columnIndex(name)
+	for rowIdx, raw := range values {
+		b.set(rowIdx, name, jsonScalar(raw))
+	}
+}
+
+func (b *tableBuilder) table() *Table {
+	for rowIdx, row := range b.rows {
+		for len(row) < len(b.headers) {
+			row = append(row, "")
+		}
+		b.rows[rowIdx] = row
+	}
+	return NewTable(b.headers, b.rows)
+}
+
+// flattenObject calls `add` with the dotted name and the value of each
+// field of the JSON object `raw`, recursing into nested objects.
+func flattenObject(raw json.RawMessage, prefix string, add func(name, value string)) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		name := prefix + key.(string)
+		if len(value) > 0 && value[0] == '{' {
+			if err := flattenObject(value, name+".", add); err != nil {
+				return err
+			}
+			continue
+		}
+		add(name, jsonScalar(value))
+	}
+	return nil
+}
+
+// jsonScalar returns the CSV value of a JSON value.
+func jsonScalar(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return ""
+	case raw[0] == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+	case raw[0] == '[' || raw[0] == '{':
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, raw); err == nil {
+			return compacted.String()
+		}
+	}
+	return string(raw)
+}
@@ -0,0 +1,94 @@
+package csv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+func TestWriteJSON(t *testing.T) {
+	table := parseTable(t, "id,name,active,score\n1,\"John \"\"JD\"\" Doe\",yes,1.5\n2,Jane,no,\n")
+	tests := []struct {
+		opts     csv.JSONOptions
+		expected string
+	}{
+		{csv.JSONOptions{Format: csv.JSONLines},
+			`{"id":"1","name":"John \"JD\" Doe","active":"yes","score":"1.5"}` + "\n" +
+				`{"id":"2","name":"Jane","active":"no","score":""}` + "\n"},
+		{csv.JSONOptions{Format: csv.JSONArray, Typed: true},
+			`[{"id":1,"name":"John \"JD\" Doe","active":true,"score":1.5},` +
+				`{"id":2,"name":"Jane","active":false,"score":null}]` + "\n"},
+		{csv.JSONOptions{Format: csv.JSONColumns, Typed: true},
+			`{"id":[1,2],"name":["John \"JD\" Doe","Jane"],"active":[true,false],"score":[1.5,null]}` + "\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := table.WriteJSON(&buf, test.opts); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("Expected %s JSON `%s`, got `%s`", test.opts.Format, test.expected, buf.String())
+		}
+	}
+
+	if err := table.WriteJSON(&bytes.Buffer{}, csv.JSONOptions{Format: "xml"}); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+	table = parseTable(t, "zip,count\n007,+1\n12,2\n")
+	var buf bytes.Buffer
+	if err := table.WriteJSON(&buf, csv.JSONOptions{Format: csv.JSONLines, Typed: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"zip":"007","count":"+1"}` + "\n" + `{"zip":"12","count":"2"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected numbers which are not all valid JSON to be strings `%s`, got `%s`", expected, buf.String())
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		format csv.JSONFormat
+		data   string
+	}{
+		{csv.JSONLines, `{"id":1,"user":{"name":"John","address":{"city":"Paris"}},"tags":["a", "b"]}` + "\n" +
+			`{"id":2,"active":true,"user":{"name":"Jane"}}` + "\n"},
+		{csv.JSONArray, `[{"id":1,"user":{"name":"John","address":{"city":"Paris"}},"tags":["a","b"]},` +
+			`{"id":2,"active":true,"user":{"name":"Jane","address":null}}]`},
+		{csv.JSONColumns, `{"id":[1,2],"user.name":["John","Jane"],"user.address.city":["Paris"],` +
+			`"tags":[["a","b"]],"active":[null,true]}`},
+	}
+	for _, test := range tests {
+		table, err := csv.ReadJSON(strings.NewReader(test.data), test.format)
+		if err != nil {
+			t.Fatalf("Failed to read %s JSON: %v", test.format, err)
+		}
+		for _, expected := range []struct{ column, first, second string }{
+			{"id", "1", "2"},
+			{"user.name", "John", "Jane"},
+			{"user.address.city", "Paris", ""},
+			{"tags", `["a","b"]`, ""},
+			{"active", "", "true"},
+		} {
+			if table.Value(0, expected.column) != expected.first || table.Value(1, expected.column) != expected.second {
+				t.Errorf("%s: expected column `%s` to be [%s %s], got %q", test.format, expected.column, expected.first, expected.second, table.Column(expected.column))
+			}
+		}
+	}
+
+	if _, err := csv.ReadJSON(strings.NewReader(`{"id":1}`), csv.JSONArray); err == nil {
+		t.Error("Expected an error for an object instead of an array")
+	}
+	for _, data := range []string{`[{"a":1}`, `[{"a":1}] garbage`, `[{"a":1}] []`} {
+		if _, err := csv.ReadJSON(strings.NewReader(data), csv.JSONArray); err == nil {
+			t.Errorf("Expected an error for the unterminated or trailing array data `%s`", data)
+		}
+	}
+	if _, err := csv.ReadJSON(strings.NewReader(`{"a":[1]} {}`), csv.JSONColumns); err == nil {
+		t.Error("Expected an error for trailing columns data")
+	}
+	if _, err := csv.ReadJSON(strings.NewReader("[{\"a\":1}]\n"), csv.JSONArray); err != nil {
+		t.Errorf("Expected trailing whitespace to be accepted, got %v", err)
+	}
+}
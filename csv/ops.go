@@ -0,0 +1,399 @@
+package csv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The operations below return new tables, which can be written with
+// `Writer.WriteTable`, and leave the original table unchanged. Columns
+// are referred to by name, and lookups return the first column with
+// that name.
+
+// Select returns a table with the columns `names` only, in that order.
+func (t *Table) Select(names ...string) (*Table, error) {
+	indexes, err := t.columnIndexes(names)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]string, len(t.Rows))
+	for rowIdx, row := range t.Rows {
+		rows[rowIdx] = pick(row, indexes)
+	}
+	return NewTable(append([]string(nil), names...), rows), nil
+}
+
+// Filter returns a table with the rows for which `predicate` returns
+// true. The rows passed to `predicate` have no line number.
+func (t *Table) Filter(predicate func(row Row) bool) *Table {
+	index := t.headerIndex()
+	rows := make([][]string, 0)
+	for _, values := range t.Rows {
+		if predicate(Row{Values: values, index: index}) {
+			rows = append(rows, append([]string(nil), values...))
+		}
+	}
+	return NewTable(append([]string(nil), t.Headers...), rows)
+}
+
+// SortKey is a column to sort a table by.
+type SortKey struct {
+	Column string
+	// Type is the type the values are compared as (defaults to
+	// `TypeString`, compared lexically). In ascending order, empty values
+	// come first, and values which cannot be converted to the type come
+	// last, compared lexically. Descending order reverses it.
+	Type       ColumnType
+	Descending bool
+}
+
+// Sort returns a table with the rows sorted by `keys`, in order of
+// precedence. The sort is stable: rows with equal keys keep their
+// order.
+func (t *Table) Sort(keys ...SortKey) (*Table, error) {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Column
+	}
+	indexes, err := t.columnIndexes(names)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := t.copyRows()
+	sort.SliceStable(rows, func(a, b int) bool {
+		for i, key := range keys {
+			c := compareValues(cell(rows[a], indexes[i]), cell(rows[b], indexes[i]), key.Type)
+			if c == 0 {
+				continue
+			}
+			if key.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return NewTable(append([]string(nil), t.Headers...), rows), nil
+}
+
+// AggregateFunc is a function aggregating the values of a group.
+type AggregateFunc string
+
+const (
+	// Count counts the rows of the group.
+	Count AggregateFunc = "count"
+	// Sum sums the non-empty values, which must be numbers, exactly if
+	// they are all integers.
+	Sum AggregateFunc = "sum"
+	// Min returns the smallest non-empty value, compared as numbers if
+	// they all are, and lexically otherwise.
+	Min AggregateFunc = "min"
+	// Max returns the largest non-empty value, compared like in `Min`.
+	Max AggregateFunc = "max"
+)
+
+// Aggregate is an aggregated column of a `GroupBy`.
+type Aggregate struct {
+	Func AggregateFunc
+	// Column is the column aggregated (unused by `Count`).
+	Column string
+	// Name is the name of the aggregated column (defaults to "count" for
+	// `Count`, and to "<func>_<column>" otherwise).
+	Name string
+}
+
+func (a Aggregate) name() string {
+	switch {
+	case len(a.Name) > 0:
+		return a.Name
+	case a.Func == Count:
+		return string(Count)
+	}
+	return string(a.Func) + "_" + a.Column
+}
+
+// GroupBy returns a table with a row for each distinct combination of
+// values of the `keys` columns, in order of first appearance, made of
+// these values followed by the `aggregates`.
+func (t *Table) GroupBy(keys []string, aggregates ...Aggregate) (*Table, error) {
+	keyIndexes, err := t.columnIndexes(keys)
+	if err != nil {
+		return nil, err
+	}
+	aggIndexes := make([]int, len(aggregates))
+	for i, agg := range aggregates {
+		switch agg.Func {
+		case Count:
+			continue
+		case Sum, Min, Max:
+		default:
+			return nil, fmt.Errorf("unsupported aggregate function `%s`", agg.Func)
+		}
+		aggIndexes[i] = t.Index(agg.Column)
+		if aggIndexes[i] < 0 {
+			return nil, &DecodeError{Column: agg.Column, Err: errColumnNotFound}
+		}
+	}
+
+	groups := make(map[string]int)
+	groupKeys := make([][]string, 0)
+	groupRows := make([][]int, 0)
+	for rowIdx, row := range t.Rows {
+		values := pick(row, keyIndexes)
+		id := rowKey(values)
+		g, ok := groups[id]
+		if !ok {
+			g = len(groupKeys)
+			groups[id] = g
+			groupKeys = append(groupKeys, values)
+			groupRows = append(groupRows, make([]int, 0))
+		}
+		groupRows[g] = append(groupRows[g], rowIdx)
+	}
+
+	headers := append([]string(nil), keys...)
+	for _, agg := range aggregates {
+		headers = append(headers, agg.name())
+	}
+	rows := make([][]string, len(groupKeys))
+	for g, values := range groupKeys {
+		for i, agg := range aggregates {
+			value, err := t.aggregate(agg, aggIndexes[i], groupRows[g])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		rows[g] = values
+	}
+	return NewTable(headers, rows), nil
+}
+
+func (t *Table) aggregate(agg Aggregate, colIdx int, rowIdxs []int) (string, error) {
+	if agg.Func == Count {
+		return strconv.Itoa(len(rowIdxs)), nil
+	}
+
+	values := make([]string, 0, len(rowIdxs))
+	valueRows := make([]int, 0, len(rowIdxs))
+	for _, rowIdx := range rowIdxs {
+		if value := strings.TrimSpace(cell(t.Rows[rowIdx], colIdx)); len(value) > 0 {
+			values = append(values, value)
+			valueRows = append(valueRows, rowIdx)
+		}
+	}
+	if agg.Func == Sum {
+		// Integers are summed exactly, unless the sum overflows
+		sum, intSum, isInt := 0.0, int64(0), true
+		for i, value := range values {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return "", &CellError{Row: valueRows[i], Column: agg.Column, Value: value, Err: err}
+			}
+			sum += f
+			if isInt {
+				n, err := strconv.ParseInt(value, 10, 64)
+				isInt = err == nil && !addOverflows(intSum, n)
+				intSum += n
+			}
+		}
+		if isInt {
+			return strconv.FormatInt(intSum, 10), nil
+		}
+		return strconv.FormatFloat(sum, 'f', -1, 64), nil
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+	valueType := TypeFloat
+	for _, value := range values {
+		if !isNumber(value) {
+			valueType = TypeString
+			break
+		}
+	}
+	result := values[0]
+	for _, value := range values[1:] {
+		c := compareValues(value, result, valueType)
+		if (agg.Func == Min && c < 0) || (agg.Func == Max && c > 0) {
+			result = value
+		}
+	}
+	return result, nil
+}
+
+// Distinct returns a table without the rows whose values of the columns
+// `names` (or of all the columns, if none) are the same as a previous
+// row's.
+func (t *Table) Distinct(names ...string) (*Table, error) {
+	var indexes []int
+	if len(names) > 0 {
+		var err error
+		if indexes, err = t.columnIndexes(names); err != nil {
+			return nil, err
+		}
+	}
+	seen := make(map[string]bool)
+	rows := make([][]string, 0)
+	for _, row := range t.Rows {
+		values := row
+		if indexes != nil {
+			values = pick(row, indexes)
+		}
+		id := rowKey(values)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		rows = append(rows, append([]string(nil), row...))
+	}
+	return NewTable(append([]string(nil), t.Headers...), rows), nil
+}
+
+// headerIndex maps the header names to the index of the first column
+// with that name.
+func (t *Table) headerIndex() map[string]int {
+	index := make(map[string]int)
+	for i := len(t.Headers) - 1; i >= 0; i-- {
+		index[t.Headers[i]] = i
+	}
+	return index
+}
+
+func (t *Table) columnIndexes(names []string) ([]int, error) {
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		indexes[i] = t.Index(name)
+		if indexes[i] < 0 {
+			return nil, &DecodeError{Column: name, Err: errColumnNotFound}
+		}
+	}
+	return indexes, nil
+}
+
+func (t *Table) copyRows() [][]string {
+	rows := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		rows[i] = append([]string(nil), row...)
+	}
+	return rows
+}
+
+// cell returns the value at index `i` of `row`, or an empty string if
+// the row is missing it.
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+func pick(row []string, indexes []int) []string {
+	values := make([]string, len(indexes))
+	for i, index := range indexes {
+		values[i] = cell(row, index)
+	}
+	return values
+}
+
+// rowKey returns a string identifying the values.
+func rowKey(values []string) string {
+	return strings.Join(values, "\x00")
+}
+
+// compareValues compares `a` and `b` as values of type `valueType`,
+// returning -1, 0 or 1. See `SortKey` for the order.
+func compareValues(a, b string, valueType ColumnType) int {
+	if ca, cb := len(a) == 0, len(b) == 0; ca || cb {
+		return compareBools(!ca, !cb)
+	}
+	switch valueType {
+	case TypeInt:
+		ia, errA := strconv.ParseInt(strings.TrimSpace(a), 10, 64)
+		ib, errB := strconv.ParseInt(strings.TrimSpace(b), 10, 64)
+		if errA == nil && errB == nil {
+			return compareInts(ia, ib)
+		}
+		if errA == nil || errB == nil {
+			return compareBools(errA != nil, errB != nil)
+		}
+	case TypeFloat:
+		fa, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		fb, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		if errA == nil && errB == nil {
+			return compareFloats(fa, fb)
+		}
+		if errA == nil || errB == nil {
+			return compareBools(errA != nil, errB != nil)
+		}
+	case TypeBool:
+		ba, errA := parseBool(strings.TrimSpace(a))
+		bb, errB := parseBool(strings.TrimSpace(b))
+		if errA == nil && errB == nil {
+			return compareBools(ba, bb)
+		}
+		if errA == nil || errB == nil {
+			return compareBools(errA != nil, errB != nil)
+		}
+	case TypeTime:
+		ta, errA := parseTime(a, DefaultTimeLayouts)
+		tb, errB := parseTime(b, DefaultTimeLayouts)
+		if errA == nil && errB == nil {
+			return compareTimes(ta, tb)
+		}
+		if errA == nil || errB == nil {
+			return compareBools(errA != nil, errB != nil)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func addOverflows(a, b int64) bool {
+	return (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
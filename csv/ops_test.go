@@ -0,0 +1,146 @@
+package csv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+const opsTestData = "country,city,population,founded\n" +
+	"FR,Paris,2148000,\n" +
+	"FR,Lyon,516000,43\n" +
+	"DE,Berlin,3645000,1237\n" +
+	"FR,Paris,2148000,\n" +
+	"DE,Hamburg,1841000,808\n"
+
+func parseOpsTestData(t *testing.T) *csv.Table {
+	t.Helper()
+	table, err := csv.ParseColumnsFrom(strings.NewReader(opsTestData), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestTableSelect(t *testing.T) {
+	table, err := parseOpsTestData(t).Select("city", "country")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchRows(t, [][]string{{"city", "country"}}, [][]string{table.Headers})
+	matchRows(t, [][]string{{"Paris", "FR"}, {"Lyon", "FR"}, {"Berlin", "DE"}, {"Paris", "FR"}, {"Hamburg", "DE"}}, table.Rows)
+
+	if _, err := parseOpsTestData(t).Select("missing"); err == nil {
+		t.Error("Expected an error for a missing column")
+	}
+}
+
+func TestTableFilter(t *testing.T) {
+	table := parseOpsTestData(t).Filter(func(row csv.Row) bool {
+		return row.Get("country") == "DE"
+	})
+	matchRows(t, [][]string{{"DE", "Berlin", "3645000", "1237"}, {"DE", "Hamburg", "1841000", "808"}}, table.Rows)
+}
+
+func TestTableSort(t *testing.T) {
+	table, err := parseOpsTestData(t).Sort(
+		csv.SortKey{Column: "country"},
+		csv.SortKey{Column: "founded", Type: csv.TypeInt, Descending: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cities := table.Column("city")
+	expected := []string{"Berlin", "Hamburg", "Lyon", "Paris", "Paris"}
+	for i := range expected {
+		if cities[i] != expected[i] {
+			t.Errorf("Expected cities sorted as %v, got %v", expected, cities)
+			break
+		}
+	}
+
+	// Large integers are compared exactly
+	table = csv.NewTable([]string{"id"}, [][]string{{"9007199254740993"}, {"9007199254740992"}})
+	table, _ = table.Sort(csv.SortKey{Column: "id", Type: csv.TypeInt})
+	if ids := table.Column("id"); ids[0] != "9007199254740992" {
+		t.Errorf("Expected large integers to be sorted, got %v", ids)
+	}
+
+	// Descending order puts empty values last
+	table, _ = parseOpsTestData(t).Sort(csv.SortKey{Column: "founded", Type: csv.TypeInt, Descending: true})
+	if founded := table.Column("founded"); founded[0] != "1237" || founded[3] != "" || founded[4] != "" {
+		t.Errorf("Expected empty values last, got %v", founded)
+	}
+
+	// Lexical order differs from the numeric one
+	table, _ = parseOpsTestData(t).Sort(csv.SortKey{Column: "founded"})
+	if founded := table.Column("founded"); founded[2] != "1237" || founded[4] != "808" {
+		t.Errorf("Expected lexical order, got %v", founded)
+	}
+}
+
+func TestTableGroupBy(t *testing.T) {
+	table, err := parseOpsTestData(t).GroupBy([]string{"country"},
+		csv.Aggregate{Func: csv.Count},
+		csv.Aggregate{Func: csv.Sum, Column: "population"},
+		csv.Aggregate{Func: csv.Min, Column: "founded"},
+		csv.Aggregate{Func: csv.Max, Column: "city", Name: "last_city"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchRows(t, [][]string{{"country", "count", "sum_population", "min_founded", "last_city"}}, [][]string{table.Headers})
+	matchRows(t, [][]string{
+		{"FR", "3", "4812000", "43", "Paris"},
+		{"DE", "2", "5486000", "808", "Hamburg"},
+	}, table.Rows)
+
+	if _, err := parseOpsTestData(t).GroupBy([]string{"country"}, csv.Aggregate{Func: csv.Sum, Column: "city"}); err == nil {
+		t.Error("Expected an error when summing non-numeric values")
+	}
+
+	table = csv.NewTable([]string{"key", "value"}, [][]string{{"a", ""}, {"a", "1"}, {"a", "x"}})
+	_, err = table.GroupBy([]string{"key"}, csv.Aggregate{Func: csv.Sum, Column: "value"})
+	var cellErr *csv.CellError
+	if !errors.As(err, &cellErr) || cellErr.Row != 2 || cellErr.Value != "x" {
+		t.Errorf("Expected an error for the cell at row 2, got %v", err)
+	}
+	table = csv.NewTable([]string{"key", "value"}, [][]string{
+		{"int", "9007199254740993"}, {"int", "1"},
+		{"float", "0.5"}, {"float", "1"},
+		{"overflow", "9223372036854775807"}, {"overflow", "1"},
+	})
+	table, err = table.GroupBy([]string{"key"}, csv.Aggregate{Func: csv.Sum, Column: "value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchRows(t, [][]string{
+		{"int", "9007199254740994"},
+		{"float", "1.5"},
+		{"overflow", "9223372036854776000"},
+	}, table.Rows)
+}
+
+func TestTableDistinct(t *testing.T) {
+	table, err := parseOpsTestData(t).Distinct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Len() != 4 {
+		t.Errorf("Expected 4 distinct rows, got %d", table.Len())
+	}
+
+	table, _ = parseOpsTestData(t).Distinct("country")
+	matchRows(t, [][]string{{"FR", "Paris", "2148000", ""}, {"DE", "Berlin", "3645000", "1237"}}, table.Rows)
+
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf, csv.WriterOptions{LineEnding: "\n"}).WriteTable(table); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "country,city,population,founded\nFR,Paris,2148000,\nDE,Berlin,3645000,1237\n"; buf.String() != expected {
+		t.Errorf("Expected written table `%s`, got `%s`", expected, buf.String())
+	}
+}
@@ -0,0 +1,318 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// defaultChunkSize is the default size of the chunks parsed
+// concurrently by `ParseParallelFrom`.
+const defaultChunkSize = 4 * 1024 * 1024
+
+// ParallelOptions configures the parsing of CSV data by
+// `ParseParallelFrom`.
+type ParallelOptions struct {
+	Options
+	// Workers is the number of chunks parsed concurrently (defaults to
+	// the number of CPUs).
+	Workers int
+	// ChunkSize is the approximate size in bytes of the chunks (defaults
+	// to 4MB). Records longer than it make larger chunks.
+	ChunkSize int
+}
+
+// ParseCsvToColumnsParallel parses a CSV file like `ParseCsvToColumns`,
+// concurrently (see `ParseParallelFrom`).
+func ParseCsvToColumnsParallel(filepath string, opts ParallelOptions) (*Table, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return NewTable(make([]string, 0), make([][]string, 0)), fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	return ParseParallelFrom(file, opts)
+}
+
+// ParseParallelFrom parses CSV data from `r` like `ParseColumnsFrom`,
+// splitting it in chunks parsed concurrently, for large data.
+//
+// The data is read sequentially and split on record boundaries: line
+// breaks inside quoted fields are found by tracking the quotes, which is
+// much faster than parsing the records. The chunks are then parsed by
+// a pool of workers, and their rows are reassembled in order. Line
+// numbers in errors are relative to the whole data.
+//
+// ### NB: limitations
+//
+// The whole data is held in memory, like with `ParseColumnsFrom`, and
+// the rows are only returned once it has been parsed.
+func ParseParallelFrom(r io.Reader, opts ParallelOptions) (*Table, error) {
+	r, decoded := prepareInput(r, opts.Options)
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	src := newParallelSource(r, decoded, workers, chunkSize)
+	defer src.close()
+	return readTable(newReader(src, decoded))
+}
+
+// chunk is a part of the data made of whole records.
+type chunk struct {
+	index int
+	data  []byte
+	line  int // number of lines before the chunk
+	err   error
+}
+
+// chunkResult holds the records parsed from a chunk, with the line
+// where each of them starts.
+type chunkResult struct {
+	index   int
+	records [][]string
+	lines   []int
+	err     error
+}
+
+// parallelSource is a `recordSource` parsing chunks of the data
+// concurrently, and returning their records in order.
+type parallelSource struct {
+	results chan chunkResult
+	done    chan struct{}
+	// slots limit the number of chunks read ahead of the one being
+	// returned, to bound the memory used.
+	slots chan struct{}
+
+	pending    map[int]chunkResult
+	next       int
+	current    chunkResult
+	holding    bool // whether `current` holds a slot
+	pos        int
+	recordLine int
+}
+
+func newParallelSource(r io.Reader, opts Options, workers int, chunkSize int) *parallelSource {
+	s := &parallelSource{
+		results: make(chan chunkResult, 2*workers),
+		done:    make(chan struct{}),
+		slots:   make(chan struct{}, 2*workers),
+		pending: make(map[int]chunkResult),
+	}
+	jobs := make(chan chunk)
+	go s.split(r, jobs, normalizeSeparator(opts.separator(), opts.quote()), opts.quote(), chunkSize, opts.MaxRecordSize)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			s.parseChunks(jobs, opts.separator(), opts.quote(), opts.MaxRecordSize)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.results)
+	}()
+	return s
+}
+
+// split reads the data and sends it to `jobs` in chunks of whole
+// records.
+func (s *parallelSource) split(r io.Reader, jobs chan<- chunk, sep string, quote byte, chunkSize int, maxSize int) {
+	defer close(jobs)
+	buf := make([]byte, 0, chunkSize)
+	index, line := 0, 0
+	send := func(c chunk) bool {
+		select {
+		case s.slots <- struct{}{}:
+		case <-s.done:
+			return false
+		}
+		select {
+		case jobs <- c:
+			return true
+		case <-s.done:
+			return false
+		}
+	}
+
+	block := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, block)
+		buf = append(buf, block[:n]...)
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			// Send the complete records read, then the error at the line
+			// being read, like `parser.readLine`.
+			end := lastRecordEnd(buf, sep, quote)
+			if end > 0 {
+				if !send(chunk{index: index, data: buf[:end], line: line}) {
+					return
+				}
+				index++
+				line += bytes.Count(buf[:end], []byte{'\n'})
+			}
+			line += bytes.Count(buf[end:], []byte{'\n'})
+			send(chunk{index: index, err: &ParseError{Line: line + 1, Err: fmt.Errorf("failed to read data, %w", err)}})
+			return
+		}
+
+		data := buf
+		if !eof {
+			end := lastRecordEnd(buf, sep, quote)
+			if end == 0 && (maxSize <= 0 || len(buf) <= maxSize) {
+				// The record is longer than the chunk
+				continue
+			}
+			if end == 0 {
+				// Let the parser report the record as too long
+				end = len(buf)
+			}
+			data = buf[:end]
+			buf = append(make([]byte, 0, chunkSize), buf[end:]...)
+		}
+		if len(data) > 0 {
+			if !send(chunk{index: index, data: data, line: line}) {
+				return
+			}
+			index++
+			line += bytes.Count(data, []byte{'\n'})
+		}
+		if eof {
+			return
+		}
+	}
+}
+
+// lastRecordEnd returns the offset following the last line break ending
+// a record in `data`, which starts with a record, or 0 if there is none.
+func lastRecordEnd(data []byte, sep string, quote byte) int {
+	const (
+		fieldStart = iota
+		unquoted
+		quoted
+		quotedQuote // a quote in a quoted field, closing it unless doubled
+	)
+	state, end := fieldStart, 0
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch state {
+		case quoted:
+			j := bytes.IndexByte(data[i:], quote)
+			if j < 0 {
+				return end
+			}
+			i += j
+			state = quotedQuote
+			continue
+		case quotedQuote:
+			if b == quote {
+				state = quoted
+				continue
+			}
+			state = unquoted
+		case fieldStart:
+			if b == quote {
+				state = quoted
+				continue
+			}
+			state = unquoted
+		}
+
+		if b == '\n' {
+			end = i + 1
+			state = fieldStart
+		} else if b == sep[0] && bytes.HasPrefix(data[i:], []byte(sep)) {
+			i += len(sep) - 1
+			state = fieldStart
+		}
+	}
+	return end
+}
+
+// parseChunks parses the chunks from `jobs` until there are no more.
+func (s *parallelSource) parseChunks(jobs <-chan chunk, sep string, quote byte, maxSize int) {
+	for c := range jobs {
+		result := chunkResult{index: c.index, err: c.err}
+		if c.err == nil {
+			p := newParser(bytes.NewReader(c.data), sep, quote)
+			p.maxSize = maxSize
+			for {
+				record, err := p.readRecord()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					result.err = offsetError(err, c.line)
+					break
+				}
+				result.records = append(result.records, record)
+				result.lines = append(result.lines, c.line+p.recordLine)
+			}
+		}
+		select {
+		case s.results <- result:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// offsetError shifts the line of a `*ParseError` by `lines`.
+func offsetError(err error, lines int) error {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+	shifted := *parseErr
+	shifted.Line += lines
+	return &shifted
+}
+
+func (s *parallelSource) readRecord() ([]string, error) {
+	for s.pos >= len(s.current.records) {
+		if s.current.err != nil {
+			return nil, s.current.err
+		}
+		if s.holding {
+			<-s.slots
+			s.holding = false
+		}
+		result, ok := s.pending[s.next]
+		for !ok {
+			received, open := <-s.results
+			if !open {
+				return nil, io.EOF
+			}
+			if received.index == s.next {
+				result, ok = received, true
+			} else {
+				s.pending[received.index] = received
+			}
+		}
+		delete(s.pending, s.next)
+		s.next++
+		s.current, s.holding, s.pos = result, true, 0
+	}
+	s.recordLine = s.current.lines[s.pos]
+	s.pos++
+	return s.current.records[s.pos-1], nil
+}
+
+func (s *parallelSource) lastRecordLine() int {
+	return s.recordLine
+}
+
+// close stops the goroutines reading and parsing the data.
+func (s *parallelSource) close() {
+	close(s.done)
+}
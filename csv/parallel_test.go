@@ -0,0 +1,80 @@
+package csv_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+func TestParseParallelFrom(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("id,name,comment\n")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&data, "%d,name %d,\"multi\nline, \"\"quoted\"\"\r\ncomment %d\"\n", i, i, i)
+		if i%7 == 0 {
+			data.WriteString("\n")
+		}
+	}
+
+	expected, err := csv.ParseColumnsFrom(strings.NewReader(data.String()), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunkSize := range []int{1, 64, 1000, 0} {
+		table, err := csv.ParseParallelFrom(strings.NewReader(data.String()), csv.ParallelOptions{Workers: 4, ChunkSize: chunkSize})
+		if err != nil {
+			t.Fatalf("Failed to parse CSV data with chunks of %d bytes: %v", chunkSize, err)
+		}
+		matchRows(t, [][]string{expected.Headers}, [][]string{table.Headers})
+		matchRows(t, expected.Rows, table.Rows)
+	}
+}
+
+func TestParseParallelFromErrors(t *testing.T) {
+	data := "a,b\n1,2\n3,4\n5,6\n7,8,9\n10,11\n"
+	opts := csv.ParallelOptions{Options: csv.Options{Ragged: csv.RaggedStrict}, Workers: 2, ChunkSize: 4}
+	table, err := csv.ParseParallelFrom(strings.NewReader(data), opts)
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 5 || !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("Expected a field count error on line 5, got %v", err)
+	}
+	if table.Len() != 3 {
+		t.Errorf("Expected the rows before the error, got %q", table.Rows)
+	}
+
+	data = "a,b\n1,2\n3,4\n5,\"6\"7\n"
+	_, err = csv.ParseParallelFrom(strings.NewReader(data), csv.ParallelOptions{Workers: 2, ChunkSize: 4})
+	if !errors.As(err, &parseErr) || parseErr.Line != 4 || !errors.Is(err, csv.ErrQuote) {
+		t.Errorf("Expected a quote error on line 4, got %v", err)
+	}
+}
+
+var benchmarkData = func() string {
+	var data strings.Builder
+	data.WriteString("id,name,email,amount,comment\n")
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&data, "%d,John Doe %d,john.doe%d@example.com,%d.%02d,\"Some comment, with \"\"quotes\"\"\"\n", i, i, i, i, i%100)
+	}
+	return data.String()
+}()
+
+func BenchmarkParseColumnsFrom(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkData)))
+	for i := 0; i < b.N; i++ {
+		if _, err := csv.ParseColumnsFrom(strings.NewReader(benchmarkData), csv.Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseParallelFrom(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkData)))
+	for i := 0; i < b.N; i++ {
+		if _, err := csv.ParseParallelFrom(strings.NewReader(benchmarkData), csv.ParallelOptions{ChunkSize: 512 * 1024}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
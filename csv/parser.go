@@ -0,0 +1,215 @@
+package csv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrQuote is reported when a quoted field is followed by
+	// something else than a separator or the end of the record.
+	ErrQuote = errors.New("extraneous or missing \" in quoted-field")
+	// ErrUnterminatedQuote is reported when the input ends inside a
+	// quoted field.
+	ErrUnterminatedQuote = errors.New("unterminated quoted-field")
+	// ErrFieldCount is reported with `RaggedStrict` for rows with fewer
+	// or more fields than the header, and by `ParseValidatedFrom` for
+	// rows with more fields.
+	ErrFieldCount = errors.New("wrong number of fields")
+	// ErrRecordTooLong is reported for records longer than
+	// `Options.MaxRecordSize`.
+	ErrRecordTooLong = errors.New("record too long")
+	// ErrDuplicateHeader is reported when several headers are mapped to
+	// the same name by `Options.HeaderNormalizer` or
+	// `Options.HeaderAliases`.
+	ErrDuplicateHeader = errors.New("duplicate header")
+)
+
+// ParseError is returned for parsing errors, with the position
+// where the error occurred.
+type ParseError struct {
+	Line   int // line where the error occurred, starting at 1
+	Column int // byte index in the line where the error occurred, starting at 1 (0 if unknown)
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parser reads RFC 4180 records: fields are separated by `sep`, may be
+// enclosed in quotes (usually double quotes) to contain separators and
+// line breaks, and quotes are escaped by doubling them. Empty lines are
+// skipped.
+type parser struct {
+	r     *bufio.Reader
+	sep   string
+	quote byte
+	line  int
+	// maxSize is the maximum size of a record in bytes, line breaks
+	// included (0 for no limit).
+	maxSize int
+
+	// recordLine is the line where the last record read starts.
+	recordLine int
+	// recordSize is the size of the record being read.
+	recordSize int
+}
+
+func newParser(r io.Reader, sep string, quote byte) *parser {
+	return &parser{
+		r:     bufio.NewReader(r),
+		sep:   normalizeSeparator(sep, quote),
+		quote: quote,
+	}
+}
+
+// normalizeSeparator supports quoted separators such as `","`, which
+// could be used to split lines with all fields quoted before this
+// package supported quoted fields. An empty separator defaults to ",".
+func normalizeSeparator(sep string, quote byte) string {
+	if len(sep) == 0 {
+		return ","
+	}
+	if len(sep) > 2 && sep[0] == quote && sep[len(sep)-1] == quote {
+		return sep[1 : len(sep)-1]
+	}
+	return sep
+}
+
+func (p *parser) lastRecordLine() int {
+	return p.recordLine
+}
+
+// readLine reads the next line, including its line ending. Errors
+// other than `io.EOF` are returned as `*ParseError`.
+func (p *parser) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := p.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if p.maxSize > 0 && p.recordSize+len(line) > p.maxSize {
+			return "", &ParseError{Line: p.line + 1, Column: p.maxSize - p.recordSize + 1, Err: ErrRecordTooLong}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return "", &ParseError{Line: p.line + 1, Err: fmt.Errorf("failed to read data, %w", err)}
+		}
+		if len(line) == 0 {
+			return "", io.EOF
+		}
+		p.line++
+		p.recordSize += len(line)
+		return string(line), nil
+	}
+}
+
+// readRecord reads the next record, returning `io.EOF` when there are
+// no more records.
+//
+// Malformed quoted fields are parsed leniently (the extraneous characters
+// are kept in the field) and the first error is returned along with the
+// record's fields.
+func (p *parser) readRecord() ([]string, error) {
+	var line string
+	var err error
+	for {
+		p.recordSize = 0
+		line, err = p.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(trimLineEnding(line)) > 0 {
+			break
+		}
+	}
+	p.recordLine = p.line
+
+	fields := make([]string, 0)
+	var field strings.Builder
+	var recordErr error
+	i := 0
+
+fields:
+	for {
+		if i < len(line) && line[i] == p.quote {
+			// Quoted field
+			quoteLine, quoteColumn := p.line, i+1
+			i++
+			for {
+				j := strings.IndexByte(line[i:], p.quote)
+				if j < 0 {
+					// The field continues on the next line
+					field.WriteString(line[i:])
+					line, err = p.readLine()
+					if err == io.EOF {
+						fields = append(fields, field.String())
+						return fields, &ParseError{Line: quoteLine, Column: quoteColumn, Err: ErrUnterminatedQuote}
+					}
+					if err != nil {
+						return fields, err
+					}
+					i = 0
+					continue
+				}
+				field.WriteString(line[i : i+j])
+				i += j + 1
+				if i < len(line) && line[i] == p.quote {
+					// Escaped quote
+					field.WriteByte(p.quote)
+					i++
+					continue
+				}
+				break
+			}
+
+			rest := line[i:]
+			if strings.HasPrefix(rest, p.sep) {
+				fields = append(fields, field.String())
+				field.Reset()
+				i += len(p.sep)
+				continue fields
+			}
+			if len(trimLineEnding(rest)) == 0 {
+				fields = append(fields, field.String())
+				break fields
+			}
+			if recordErr == nil {
+				recordErr = &ParseError{Line: p.line, Column: i + 1, Err: ErrQuote}
+			}
+			// The rest of the field is parsed as unquoted.
+		}
+
+		// Unquoted field
+		rest := line[i:]
+		end := strings.Index(rest, p.sep)
+		if end < 0 {
+			field.WriteString(trimLineEnding(rest))
+			fields = append(fields, field.String())
+			break fields
+		}
+		field.WriteString(rest[:end])
+		fields = append(fields, field.String())
+		field.Reset()
+		i += end + len(p.sep)
+	}
+	return fields, recordErr
+}
+
+func trimLineEnding(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
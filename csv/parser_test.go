@@ -0,0 +1,152 @@
+package csv_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golib/csv"
+)
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.csv")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func matchRows(t *testing.T, expected, rows [][]string) {
+	t.Helper()
+	if len(rows) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d (%q)", len(expected), len(rows), rows)
+	}
+	for i := range expected {
+		if len(rows[i]) != len(expected[i]) {
+			t.Errorf("Expected row %d to be %q, got %q", i, expected[i], rows[i])
+			continue
+		}
+		for j := range expected[i] {
+			if rows[i][j] != expected[i][j] {
+				t.Errorf("Expected item at row %d and col %d to be `%s`, got `%s`", i, j, expected[i][j], rows[i][j])
+			}
+		}
+	}
+}
+
+func TestExtractCsvLineItems(t *testing.T) {
+	cases := map[string][]string{
+		`a,b,c`:                   {"a", "b", "c"},
+		`"Doe, John",42,`:         {"Doe, John", "42", ""},
+		`"say ""hi""",x` + "\r\n": {`say "hi"`, "x"},
+		`a,"b"c,d`:                {"a", "bc", "d"},
+		``:                        {""},
+	}
+	for line, expected := range cases {
+		matchRows(t, [][]string{expected}, [][]string{csv.ExtractCsvLineItems(line, ",")})
+	}
+
+	// An empty separator defaults to ","
+	matchRows(t, [][]string{{"a", "b"}}, [][]string{csv.ExtractCsvLineItems("a,b", "")})
+
+	// Quoted separators are supported for files with all fields quoted
+	matchRows(t, [][]string{{"a", "b,c", "d"}}, [][]string{csv.ExtractCsvLineItems(`"a","b,c","d"`, `","`)})
+}
+
+func TestParseCsvToRowsRFC4180(t *testing.T) {
+	content := "name,comment,age\r\n" +
+		"\"Doe, John\",\"multi\r\nline \"\"quoted\"\"\",42\r\n" +
+		"\r\n" +
+		"Jane,,\"\"\r\n"
+	headers, rows, err := csv.ParseCsvToRows(writeTestFile(t, content), ",")
+	if err != nil {
+		t.Fatalf("Failed to parse CSV file: %v", err)
+	}
+	if headers["age"] != 2 {
+		t.Errorf("Expected `age` header at index 2, got %d", headers["age"])
+	}
+	matchRows(t, [][]string{
+		{"Doe, John", "multi\r\nline \"quoted\"", "42"},
+		{"Jane", "", ""},
+	}, rows)
+}
+
+func TestParseCsvToRowsErrors(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		line    int
+		column  int
+		err     error
+	}{
+		"unterminated": {"a,b\n1,\"2\n3\n", 2, 3, csv.ErrUnterminatedQuote},
+		"extraneous":   {"a,b\n1,\"2\"x\n", 2, 6, csv.ErrQuote},
+	}
+	for name, c := range cases {
+		_, _, err := csv.ParseCsvToRows(writeTestFile(t, c.content), ",")
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: expected a parse error, got %v", name, err)
+			continue
+		}
+		if parseErr.Line != c.line || parseErr.Column != c.column || !errors.Is(err, c.err) {
+			t.Errorf("%s: expected error at line %d, column %d (%v), got %v", name, c.line, c.column, c.err, err)
+		}
+	}
+}
+
+func TestParseLongRecords(t *testing.T) {
+	long := strings.Repeat("x", 1024*1024)
+	data := "a,b\n1,\"" + long + "\n" + long + "\"\n2,3\n"
+	_, rows, err := csv.ParseCsvToRows(writeTestFile(t, data), ",")
+	if err != nil {
+		t.Fatalf("Failed to parse long records: %v", err)
+	}
+	if len(rows) != 2 || len(rows[0][1]) != 2*len(long)+1 || rows[1][0] != "2" {
+		t.Errorf("Expected long records to be parsed entirely")
+	}
+
+	opts := csv.Options{MaxRecordSize: 3 * len(long) / 2}
+	_, rows, err = csv.ParseRowsFrom(strings.NewReader(data), opts)
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 || !errors.Is(err, csv.ErrRecordTooLong) {
+		t.Errorf("Expected a too long record error on line 3, got %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("Expected no rows before the error, got %d", len(rows))
+	}
+
+	_, err = csv.ParseParallelFrom(strings.NewReader(data), csv.ParallelOptions{Options: opts, ChunkSize: 1024})
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 || !errors.Is(err, csv.ErrRecordTooLong) {
+		t.Errorf("Expected a too long record error on line 3, got %v", err)
+	}
+}
+
+func TestParseReadErrors(t *testing.T) {
+	readErr := errors.New("connection reset")
+	data := "a,b\n1,2\n3,\"4\n"
+	r := io.MultiReader(strings.NewReader(data), iotest.ErrReader(readErr))
+	_, rows, err := csv.ParseRowsFrom(r, csv.Options{})
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 4 || !errors.Is(err, readErr) {
+		t.Errorf("Expected a read error on line 4, got %v", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("Expected the rows before the error, got %q", rows)
+	}
+
+	for _, chunkSize := range []int{4, 0} {
+		r = io.MultiReader(strings.NewReader(data), iotest.ErrReader(readErr))
+		table, err := csv.ParseParallelFrom(r, csv.ParallelOptions{Workers: 2, ChunkSize: chunkSize})
+		if !errors.As(err, &parseErr) || parseErr.Line != 4 || !errors.Is(err, readErr) {
+			t.Errorf("Expected a read error on line 4 with chunks of %d bytes, got %v", chunkSize, err)
+		}
+		if table.Len() != 1 {
+			t.Errorf("Expected the rows before the error with chunks of %d bytes, got %q", chunkSize, table.Rows)
+		}
+	}
+}
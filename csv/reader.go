@@ -0,0 +1,253 @@
+package csv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reader reads CSV data row by row, so large files can be processed
+// in constant memory. The first record is read as the header.
+//
+// Example:
+//
+// ```
+// r := NewReader(file, Options{})
+// for r.Next() {
+//   row := r.Row()
+//   row.Get("col1") // returns the value for "col1" column
+// }
+// if err := r.Err(); err != nil {
+//   ...
+// }
+// ```
+//
+type Reader struct {
+	p        recordSource
+	sep      string
+	noHeader bool
+	ragged   RaggedPolicy
+	overflow string
+	headers  []string
+	width    int // number of fields expected in each record
+	index    map[string]int
+	row      Row
+	err      error
+	started  bool
+
+	// normalize and aliases map the header names, if set.
+	normalize func(string) string
+	aliases   map[string]string
+
+	// pending is the first row when the data has no header.
+	pending *Row
+}
+
+// Row is a row read by a `Reader`, with header-indexed access to its
+// values.
+type Row struct {
+	// Values are the row's values, in the order of the header.
+	Values []string
+	// Line is the line where the row starts, starting at 1.
+	Line  int
+	index map[string]int
+}
+
+// NewReader returns a reader of the CSV data from `r`, parsed like in
+// `ParseRowsFrom`.
+func NewReader(r io.Reader, opts Options) *Reader {
+	r, opts = prepareInput(r, opts)
+	p := newParser(r, opts.separator(), opts.quote())
+	p.maxSize = opts.MaxRecordSize
+	return newReader(p, opts)
+}
+
+// recordSource reads the records of CSV data.
+type recordSource interface {
+	// readRecord reads the next record, returning `io.EOF` when there
+	// are no more records.
+	readRecord() ([]string, error)
+	// lastRecordLine returns the line where the last record read starts.
+	lastRecordLine() int
+}
+
+func newReader(p recordSource, opts Options) *Reader {
+	return &Reader{
+		p:         p,
+		sep:       normalizeSeparator(opts.separator(), opts.quote()),
+		noHeader:  opts.NoHeader,
+		ragged:    opts.Ragged,
+		overflow:  opts.overflowColumn(),
+		normalize: opts.HeaderNormalizer,
+		aliases:   opts.HeaderAliases,
+		index:     make(map[string]int),
+	}
+}
+
+// Headers returns the header names, reading the header record if no
+// row has been read yet.
+func (r *Reader) Headers() []string {
+	r.readHeaders()
+	return r.headers
+}
+
+// HeaderIndex returns a map mapping the header names to the index of
+// the values in the rows. If a header name is repeated, the first
+// column with that name is used, like in `Table`.
+func (r *Reader) HeaderIndex() map[string]int {
+	r.readHeaders()
+	return r.index
+}
+
+func (r *Reader) readHeaders() {
+	if r.started {
+		return
+	}
+	r.started = true
+	headers, err := r.p.readRecord()
+	if err == io.EOF {
+		return
+	}
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.width = len(headers)
+	if r.noHeader {
+		r.pending = &Row{Values: r.shape(headers), Line: r.p.lastRecordLine(), index: r.index}
+		generated := make([]string, len(headers))
+		for i := range headers {
+			generated[i] = fmt.Sprintf("col%d", i)
+		}
+		headers = generated
+	}
+	if r.ragged == RaggedOverflow {
+		headers = append(headers, r.overflow)
+	}
+	if r.normalize != nil || len(r.aliases) > 0 {
+		if headers, err = r.mapHeaders(headers); err != nil {
+			r.err = err
+			return
+		}
+	}
+	r.headers = headers
+	for i, header := range headers {
+		if _, ok := r.index[header]; !ok {
+			r.index[header] = i
+		}
+	}
+}
+
+// mapHeaders normalizes the header names and resolves their aliases,
+// checking that they remain unique.
+func (r *Reader) mapHeaders(headers []string) ([]string, error) {
+	aliases := r.aliases
+	if r.normalize != nil && len(r.aliases) > 0 {
+		aliases = make(map[string]string, len(r.aliases))
+		for alias, name := range r.aliases {
+			aliases[r.normalize(alias)] = name
+		}
+	}
+
+	mapped := make([]string, len(headers))
+	sources := make(map[string]string, len(headers))
+	for i, header := range headers {
+		name := header
+		if r.normalize != nil {
+			name = r.normalize(name)
+		}
+		if canonical, ok := aliases[name]; ok {
+			name = canonical
+		}
+		if source, ok := sources[name]; ok {
+			return nil, &ParseError{
+				Line: r.p.lastRecordLine(),
+				Err:  fmt.Errorf("%w: `%s` and `%s` both map to `%s`", ErrDuplicateHeader, source, header, name),
+			}
+		}
+		sources[name] = header
+		mapped[i] = name
+	}
+	return mapped, nil
+}
+
+// Next advances to the next row, returning false when there are no
+// more rows or an error occurred (see `Err`).
+func (r *Reader) Next() bool {
+	r.readHeaders()
+	if r.err != nil || r.headers == nil {
+		return false
+	}
+	if r.pending != nil {
+		r.row = *r.pending
+		r.pending = nil
+		return true
+	}
+	values, err := r.p.readRecord()
+	if err == io.EOF {
+		r.row = Row{}
+		return false
+	}
+	if err != nil {
+		r.err = err
+		return false
+	}
+	if r.ragged == RaggedStrict && len(values) != r.width {
+		r.err = &ParseError{
+			Line:   r.p.lastRecordLine(),
+			Column: 1,
+			Err:    fmt.Errorf("%w: expected %d, got %d", ErrFieldCount, r.width, len(values)),
+		}
+		return false
+	}
+	r.row = Row{Values: r.shape(values), Line: r.p.lastRecordLine(), index: r.index}
+	return true
+}
+
+// shape pads or truncates the values of a record according to the
+// ragged row policy.
+func (r *Reader) shape(values []string) []string {
+	switch r.ragged {
+	case RaggedPad, RaggedTruncate, RaggedOverflow:
+		if len(values) < r.width {
+			padded := make([]string, r.width)
+			copy(padded, values)
+			values = padded
+		}
+	}
+	switch r.ragged {
+	case RaggedTruncate:
+		values = values[:r.width]
+	case RaggedOverflow:
+		extra := strings.Join(values[r.width:], r.sep)
+		values = append(values[:r.width:r.width], extra)
+	}
+	return values
+}
+
+// Row returns the current row.
+func (r *Reader) Row() Row {
+	return r.row
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Lookup returns the value of the `col` column and true, or false if
+// the column does not exist or is missing from the row.
+func (row Row) Lookup(col string) (string, bool) {
+	i, ok := row.index[col]
+	if !ok || i >= len(row.Values) {
+		return "", false
+	}
+	return row.Values[i], true
+}
+
+// Get returns the value of the `col` column, or an empty string if the
+// column does not exist or is missing from the row.
+func (row Row) Get(col string) string {
+	value, _ := row.Lookup(col)
+	return value
+}
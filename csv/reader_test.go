@@ -0,0 +1,104 @@
+package csv_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+func TestReader(t *testing.T) {
+	data := "col1,col2\nr0c1,r0c2\n\"multi\nline\",r1c2\nshort\n"
+	r := csv.NewReader(strings.NewReader(data), csv.Options{})
+
+	headers := r.Headers()
+	if len(headers) != 2 || headers[1] != "col2" {
+		t.Errorf("Expected headers `col1` and `col2`, got %v", headers)
+	}
+
+	expected := []struct {
+		col1 string
+		col2 string
+		line int
+	}{
+		{"r0c1", "r0c2", 2},
+		{"multi\nline", "r1c2", 3},
+		{"short", "", 5},
+	}
+	i := 0
+	for r.Next() {
+		row := r.Row()
+		if i >= len(expected) {
+			t.Fatalf("Unexpected row %v", row.Values)
+		}
+		if row.Get("col1") != expected[i].col1 || row.Get("col2") != expected[i].col2 || row.Line != expected[i].line {
+			t.Errorf("Expected row %d to be %+v, got %q at line %d", i, expected[i], row.Values, row.Line)
+		}
+		i++
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Failed to read CSV data: %v", err)
+	}
+	if i != len(expected) {
+		t.Errorf("Expected %d rows, got %d", len(expected), i)
+	}
+
+	if _, ok := r.Row().Lookup("col1"); ok {
+		t.Errorf("Expected no current row after the end of the data")
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	r := csv.NewReader(strings.NewReader("a,b\n1,\"2\n"), csv.Options{})
+	for r.Next() {
+	}
+	if r.Err() == nil {
+		t.Errorf("Expected an error for an unterminated quoted field")
+	}
+}
+
+func TestNormalizeHeaders(t *testing.T) {
+	opts := csv.Options{
+		HeaderNormalizer: csv.ParameterizeHeader,
+		HeaderAliases:    map[string]string{"E-Mail": "email", "mail": "email", "given_name": "first_name"},
+	}
+	cases := []string{
+		"First Name,LAST-NAME,E-Mail\nJohn,Doe,john@doe.com\n",
+		"firstName,lastName,mail\nJohn,Doe,john@doe.com\n",
+		" Given Name ,last_name,email\nJohn,Doe,john@doe.com\n",
+	}
+	for _, data := range cases {
+		table, err := csv.ParseColumnsFrom(strings.NewReader(data), opts)
+		if err != nil {
+			t.Fatalf("Failed to parse `%s`: %v", data, err)
+		}
+		matchRows(t, [][]string{{"first_name", "last_name", "email"}}, [][]string{table.Headers})
+	}
+
+	_, _, err := csv.ParseRowsFrom(strings.NewReader("First Name,first_name\nJohn,Doe\n"), opts)
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 1 || !errors.Is(err, csv.ErrDuplicateHeader) {
+		t.Errorf("Expected a duplicate header error on line 1, got %v", err)
+	}
+	_, _, err = csv.ParseRowsFrom(strings.NewReader("mail,email\nJohn,Doe\n"), csv.Options{HeaderAliases: opts.HeaderAliases})
+	if !errors.Is(err, csv.ErrDuplicateHeader) {
+		t.Errorf("Expected a duplicate header error for aliases, got %v", err)
+	}
+
+	// Without normalization, repeated headers are kept
+	table, err := csv.ParseColumnsFrom(strings.NewReader("a,a\n1,2\n"), csv.Options{})
+	if err != nil || len(table.Headers) != 2 {
+		t.Errorf("Expected repeated headers to be kept, got %v (%v)", table.Headers, err)
+	}
+
+	// Lookups of repeated headers use the first column on all paths
+	r := csv.NewReader(strings.NewReader("a,a\n1,2\n"), csv.Options{})
+	if !r.Next() || r.Row().Get("a") != "1" || r.HeaderIndex()["a"] != 0 {
+		t.Errorf("Expected the reader to use the first `a` column, got %q", r.Row().Values)
+	}
+	filtered := table.Filter(func(row csv.Row) bool { return row.Get("a") == "1" })
+	if filtered.Len() != 1 || table.Column("a")[0] != "1" {
+		t.Errorf("Expected the table to use the first `a` column, got %q", filtered.Rows)
+	}
+}
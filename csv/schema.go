@@ -0,0 +1,204 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ColumnType is the type of the values of a column.
+type ColumnType string
+
+const (
+	// TypeString accepts any value.
+	TypeString ColumnType = "string"
+	// TypeInt accepts integers.
+	TypeInt ColumnType = "int"
+	// TypeFloat accepts decimal numbers.
+	TypeFloat ColumnType = "float"
+	// TypeBool accepts booleans (true/false, yes/no, 1/0...).
+	TypeBool ColumnType = "bool"
+	// TypeTime accepts times matching the column's time layouts.
+	TypeTime ColumnType = "time"
+)
+
+var (
+	// ErrMissingColumn is reported when a required column is missing
+	// from the header.
+	ErrMissingColumn = errors.New("required column is missing")
+	// ErrEmptyValue is reported for empty values in non-empty columns.
+	ErrEmptyValue = errors.New("value must not be empty")
+	// ErrInvalidType is reported for values not matching the column's
+	// type.
+	ErrInvalidType = errors.New("value does not match the column type")
+	// ErrPatternMismatch is reported for values not matching the
+	// column's pattern.
+	ErrPatternMismatch = errors.New("value does not match the column pattern")
+	// ErrNotInEnum is reported for values not in the column's allowed
+	// values.
+	ErrNotInEnum = errors.New("value is not one of the allowed values")
+	// ErrDuplicateValue is reported for repeated values in unique
+	// columns.
+	ErrDuplicateValue = errors.New("value must be unique")
+)
+
+// ColumnSchema defines the constraints on a column. Empty values are
+// only checked by `NotEmpty`.
+type ColumnSchema struct {
+	Name string
+	// Required columns must be present in the header.
+	Required bool
+	NotEmpty bool
+	// Type defaults to `TypeString`.
+	Type ColumnType
+	// TimeLayouts are the layouts accepted for `TypeTime` columns
+	// (defaults to `DefaultTimeLayouts`).
+	TimeLayouts []string
+	Pattern     *regexp.Regexp
+	// Enum lists the allowed values, if not empty.
+	Enum   []string
+	Unique bool
+}
+
+// Schema defines the constraints on CSV data.
+type Schema struct {
+	Columns []ColumnSchema
+}
+
+// ValidationError describes a value (or a header) not matching the
+// schema.
+type ValidationError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("line %d, column `%s`: %v (`%s`)", e.Line, e.Column, e.Err, e.Value)
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ParseValidatedFrom parses CSV data from `r` like `ParseColumnsFrom`,
+// validating each row against `schema`. Instead of aborting on the first
+// invalid row, it collects a `ValidationError` for each invalid value
+// and returns a table with the valid rows only.
+//
+// The returned error is only set if the data could not be read or
+// parsed.
+func ParseValidatedFrom(r io.Reader, opts Options, schema Schema) (*Table, []*ValidationError, error) {
+	validationErrors := make([]*ValidationError, 0)
+	rows := make([][]string, 0)
+	reader := NewReader(r, opts)
+	headers := reader.Headers()
+	if headers == nil {
+		return NewTable(make([]string, 0), rows), validationErrors, reader.Err()
+	}
+
+	index := reader.HeaderIndex()
+	for _, col := range schema.Columns {
+		if _, ok := index[col.Name]; !ok && col.Required {
+			validationErrors = append(validationErrors, &ValidationError{Line: 1, Column: col.Name, Err: ErrMissingColumn})
+		}
+	}
+
+	seen := make([]map[string]int, len(schema.Columns))
+	for i := range seen {
+		seen[i] = make(map[string]int)
+	}
+	for reader.Next() {
+		row := reader.Row()
+		rowErrors := make([]*ValidationError, 0)
+		// Unique values are only recorded once the whole row is valid, so
+		// rejected rows don't make later rows duplicates
+		unique := make(map[int]string)
+		if len(row.Values) > len(headers) {
+			rowErrors = append(rowErrors, &ValidationError{
+				Line:  row.Line,
+				Value: strings.Join(row.Values[len(headers):], ","),
+				Err:   fmt.Errorf("%w: expected %d, got %d", ErrFieldCount, len(headers), len(row.Values)),
+			})
+		}
+		for i, col := range schema.Columns {
+			value, ok := row.Lookup(col.Name)
+			if !ok {
+				if _, inHeader := index[col.Name]; !inHeader {
+					continue
+				}
+			}
+			if err := col.validate(value); err != nil {
+				rowErrors = append(rowErrors, &ValidationError{Line: row.Line, Column: col.Name, Value: value, Err: err})
+				continue
+			}
+			if col.Unique && len(value) > 0 {
+				if firstLine, ok := seen[i][value]; ok {
+					rowErrors = append(rowErrors, &ValidationError{
+						Line:   row.Line,
+						Column: col.Name,
+						Value:  value,
+						Err:    fmt.Errorf("%w: already on line %d", ErrDuplicateValue, firstLine),
+					})
+					continue
+				}
+				unique[i] = value
+			}
+		}
+		if len(rowErrors) > 0 {
+			validationErrors = append(validationErrors, rowErrors...)
+			continue
+		}
+		for i, value := range unique {
+			seen[i][value] = row.Line
+		}
+		rows = append(rows, row.Values)
+	}
+	return NewTable(headers, rows), validationErrors, reader.Err()
+}
+
+func (col ColumnSchema) validate(value string) error {
+	if len(value) == 0 {
+		if col.NotEmpty {
+			return ErrEmptyValue
+		}
+		return nil
+	}
+
+	var err error
+	switch col.Type {
+	case TypeInt:
+		_, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case TypeFloat:
+		_, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case TypeBool:
+		_, err = parseBool(strings.TrimSpace(value))
+	case TypeTime:
+		layouts := col.TimeLayouts
+		if len(layouts) == 0 {
+			layouts = DefaultTimeLayouts
+		}
+		_, err = parseTime(value, layouts)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: expected %s", ErrInvalidType, col.Type)
+	}
+
+	if col.Pattern != nil && !col.Pattern.MatchString(value) {
+		return fmt.Errorf("%w `%s`", ErrPatternMismatch, col.Pattern)
+	}
+	if len(col.Enum) > 0 {
+		for _, allowed := range col.Enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w %q", ErrNotInEnum, col.Enum)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package csv_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+func TestParseValidatedFrom(t *testing.T) {
+	schema := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "id", Required: true, NotEmpty: true, Type: csv.TypeInt, Unique: true},
+		{Name: "email", Pattern: regexp.MustCompile(`^[^@]+@[^@]+$`)},
+		{Name: "status", Enum: []string{"active", "inactive"}},
+		{Name: "created", Type: csv.TypeTime},
+		{Name: "country", Required: true},
+	}}
+	data := "id,email,status,created\n" +
+		"1,john@doe.com,active,2021-04-30\n" +
+		"x,jane@doe.com,active,\n" +
+		"1,nope,deleted,yesterday\n" +
+		"2,,inactive,2021-04-30,extra\n" +
+		"3,,,\n"
+	table, validationErrors, err := csv.ParseValidatedFrom(strings.NewReader(data), csv.Options{}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if table.Len() != 2 || table.Value(0, "id") != "1" || table.Value(1, "id") != "3" {
+		t.Errorf("Expected only the valid rows to be returned, got %q", table.Rows)
+	}
+
+	expected := []struct {
+		line   int
+		column string
+		value  string
+		err    error
+	}{
+		{1, "country", "", csv.ErrMissingColumn},
+		{3, "id", "x", csv.ErrInvalidType},
+		{4, "id", "1", csv.ErrDuplicateValue},
+		{4, "email", "nope", csv.ErrPatternMismatch},
+		{4, "status", "deleted", csv.ErrNotInEnum},
+		{4, "created", "yesterday", csv.ErrInvalidType},
+		{5, "", "extra", csv.ErrFieldCount},
+	}
+	if len(validationErrors) != len(expected) {
+		t.Fatalf("Expected %d validation errors, got %d (%v)", len(expected), len(validationErrors), validationErrors)
+	}
+	for i, e := range expected {
+		got := validationErrors[i]
+		if got.Line != e.line || got.Column != e.column || got.Value != e.value || !errors.Is(got, e.err) {
+			t.Errorf("Expected error %d to be %+v, got `%v`", i, e, got)
+		}
+	}
+}
+
+func TestParseValidatedUniqueAfterRejectedRow(t *testing.T) {
+	schema := csv.Schema{Columns: []csv.ColumnSchema{
+		{Name: "id", Type: csv.TypeInt, Unique: true},
+		{Name: "status", Enum: []string{"active", "inactive"}},
+	}}
+	data := "id,status\n" +
+		"1,deleted\n" +
+		"1,active\n"
+	table, validationErrors, err := csv.ParseValidatedFrom(strings.NewReader(data), csv.Options{}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validationErrors) != 1 || validationErrors[0].Line != 2 || !errors.Is(validationErrors[0], csv.ErrNotInEnum) {
+		t.Errorf("Expected only the rejected row to be reported, got %v", validationErrors)
+	}
+	if table.Len() != 1 || table.Value(0, "status") != "active" {
+		t.Errorf("Expected the valid row to be returned, got %q", table.Rows)
+	}
+}
@@ -0,0 +1,224 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffSize is the size of the sample read to detect the dialect of
+// CSV data when `Options.Detect` is set.
+const sniffSize = 64 * 1024
+
+// sniffLines is the maximum number of lines of the sample analyzed.
+const sniffLines = 20
+
+// sniffSeparators are the separators `Sniff` may detect, by order of
+// preference.
+var sniffSeparators = []string{",", ";", "\t", "|"}
+
+// Dialect describes the format of CSV data, as detected by `Sniff`.
+type Dialect struct {
+	Separator string
+	Quote     byte
+	Encoding  Encoding
+	HasHeader bool
+}
+
+// Sniff detects the dialect of CSV data from a sample of its first bytes:
+//
+//   - the encoding, from the byte order mark or, without one, UTF-16
+//     is detected from its null bytes, and data which is not valid UTF-8
+//     is assumed to be Windows-1252;
+//   - the separator, among `,`, `;`, tab and `|`, as the one splitting
+//     the lines in the most consistent number of fields;
+//   - the quote character, `"` or `'`;
+//   - the presence of a header, assumed unless the first row contains
+//     numbers or looks like the following ones.
+func Sniff(sample []byte) Dialect {
+	dialect := Dialect{
+		Separator: ",",
+		Quote:     '"',
+		Encoding:  sniffEncoding(sample),
+		HasHeader: true,
+	}
+
+	text := string(decodeBytes(sample, dialect.Encoding))
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	if len(sample) >= sniffSize && len(lines) > 1 {
+		// The last line may be truncated
+		lines = lines[:len(lines)-1]
+	}
+	nonEmpty := make([]string, 0, sniffLines)
+	for _, line := range lines {
+		if len(strings.TrimSpace(line)) > 0 {
+			nonEmpty = append(nonEmpty, line)
+		}
+		if len(nonEmpty) == sniffLines {
+			break
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return dialect
+	}
+
+	dialect.Quote = sniffQuote(nonEmpty)
+	dialect.Separator = sniffSeparator(nonEmpty, dialect.Quote)
+	dialect.HasHeader = sniffHeader(nonEmpty, dialect.Separator, dialect.Quote)
+	return dialect
+}
+
+func sniffEncoding(sample []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+
+	// UTF-16 text mostly made of ASCII characters has a null byte in
+	// every other position.
+	evenNulls, oddNulls := 0, 0
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				evenNulls++
+			} else {
+				oddNulls++
+			}
+		}
+	}
+	half := len(sample) / 2
+	if half > 0 && oddNulls > half/2 && evenNulls == 0 {
+		return EncodingUTF16LE
+	}
+	if half > 0 && evenNulls > half/2 && oddNulls == 0 {
+		return EncodingUTF16BE
+	}
+
+	valid := sample
+	if len(sample) >= sniffSize {
+		// The sample may end in the middle of a character
+		for i := 0; i < utf8.UTFMax && len(valid) > 0 && !utf8.Valid(valid); i++ {
+			valid = valid[:len(valid)-1]
+		}
+	}
+	if !utf8.Valid(valid) {
+		return EncodingWindows1252
+	}
+	return EncodingUTF8
+}
+
+// sniffQuote returns `'` if fields are enclosed in single quotes, and
+// `"` otherwise.
+func sniffQuote(lines []string) byte {
+	double, single := 0, 0
+	for _, line := range lines {
+		double += strings.Count(line, `"`)
+		single += strings.Count(line, `'`)
+	}
+	if single > 0 && double == 0 && single%2 == 0 {
+		for _, line := range lines {
+			if strings.HasPrefix(line, "'") || strings.HasSuffix(line, "'") {
+				return '\''
+			}
+		}
+	}
+	return '"'
+}
+
+func sniffSeparator(lines []string, quote byte) string {
+	best, bestScore, bestFields := sniffSeparators[0], -1, 0
+	for _, sep := range sniffSeparators {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			counts[len(splitLine(line, sep, quote))]++
+		}
+		// The most frequent number of fields, and the number of lines
+		// having it.
+		fields, score := 0, 0
+		for n, count := range counts {
+			if count > score || (count == score && n > fields) {
+				fields, score = n, count
+			}
+		}
+		if fields < 2 {
+			continue
+		}
+		if score > bestScore || (score == bestScore && fields > bestFields) {
+			best, bestScore, bestFields = sep, score, fields
+		}
+	}
+	return best
+}
+
+func sniffHeader(lines []string, sep string, quote byte) bool {
+	if len(lines) < 2 {
+		return true
+	}
+	first := splitLine(lines[0], sep, quote)
+	others := make([][]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		others = append(others, splitLine(line, sep, quote))
+	}
+
+	votes := 0
+	for col, value := range first {
+		if isNumber(value) {
+			// Header names are rarely numbers
+			votes--
+			continue
+		}
+		numbers, total := 0, 0
+		for _, row := range others {
+			if col < len(row) && len(row[col]) > 0 {
+				total++
+				if isNumber(row[col]) {
+					numbers++
+				}
+			}
+		}
+		if total > 0 && numbers*2 > total {
+			votes++
+		}
+	}
+	return votes >= 0
+}
+
+func splitLine(line string, sep string, quote byte) []string {
+	fields, _ := newParser(strings.NewReader(line), sep, quote).readRecord()
+	return fields
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil
+}
+
+// prepareInput detects the dialect of the data if requested, completing
+// the options with it, and returns a reader of the data decoded to UTF-8.
+func prepareInput(r io.Reader, opts Options) (io.Reader, Options) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	if opts.Detect {
+		sample, _ := br.Peek(sniffSize)
+		dialect := Sniff(sample)
+		if len(opts.Separator) == 0 {
+			opts.Separator = dialect.Separator
+		}
+		if opts.Quote == 0 {
+			opts.Quote = dialect.Quote
+		}
+		if len(opts.Encoding) == 0 {
+			opts.Encoding = dialect.Encoding
+		}
+		if !dialect.HasHeader {
+			opts.NoHeader = true
+		}
+	}
+	return decodeReader(br, opts.Encoding), opts
+}
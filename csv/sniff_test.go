@@ -0,0 +1,87 @@
+package csv_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"golib/csv"
+)
+
+func encodeUTF16LE(s string, bom bool) []byte {
+	var buf bytes.Buffer
+	if bom {
+		buf.Write([]byte{0xff, 0xfe})
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		binary.Write(&buf, binary.LittleEndian, unit)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	cases := map[string]struct {
+		sample   []byte
+		expected csv.Dialect
+	}{
+		"semicolon": {
+			[]byte("name;city;amount\nJohn;\"Paris; France\";1,5\nJane;Lyon;2,5\n"),
+			csv.Dialect{Separator: ";", Quote: '"', Encoding: csv.EncodingUTF8, HasHeader: true},
+		},
+		"tab_without_header": {
+			[]byte("1\tJohn\t12.5\n2\tJane\t13\n"),
+			csv.Dialect{Separator: "\t", Quote: '"', Encoding: csv.EncodingUTF8, HasHeader: false},
+		},
+		"pipe_single_quotes": {
+			[]byte("'id'|'name'\n'1'|'John, Jr'\n"),
+			csv.Dialect{Separator: "|", Quote: '\'', Encoding: csv.EncodingUTF8, HasHeader: true},
+		},
+		"windows_1252": {
+			[]byte("nom,ville\nRen\xe9,Orl\xe9ans\n"),
+			csv.Dialect{Separator: ",", Quote: '"', Encoding: csv.EncodingWindows1252, HasHeader: true},
+		},
+		"utf16_bom": {
+			encodeUTF16LE("a;b\n1;2\n", true),
+			csv.Dialect{Separator: ";", Quote: '"', Encoding: csv.EncodingUTF16LE, HasHeader: true},
+		},
+		"utf16_without_bom": {
+			encodeUTF16LE("a\tb\n1\t2\n", false),
+			csv.Dialect{Separator: "\t", Quote: '"', Encoding: csv.EncodingUTF16LE, HasHeader: true},
+		},
+	}
+	for name, c := range cases {
+		if dialect := csv.Sniff(c.sample); dialect != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", name, c.expected, dialect)
+		}
+	}
+}
+
+func TestParseWithDetection(t *testing.T) {
+	data := []byte("nom;ville\nRen\xe9;\"Orl\xe9ans\"\n")
+	table, err := csv.ParseColumnsFrom(bytes.NewReader(data), csv.Options{Detect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(table.Headers, ",") != "nom,ville" || table.Value(0, "nom") != "René" || table.Value(0, "ville") != "Orléans" {
+		t.Errorf("Unexpected table %q %q", table.Headers, table.Rows)
+	}
+
+	// The UTF-8 BOM is skipped even without detection
+	headers, _, err := csv.ParseRowsFrom(strings.NewReader("\xef\xbb\xbfcol0,col1\nr0c0,r0c1\n"), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := headers["col0"]; !ok {
+		t.Errorf("Expected the BOM to be skipped from the first header, got %q", headers)
+	}
+
+	table, err = csv.ParseColumnsFrom(bytes.NewReader(encodeUTF16LE("1\tJohn\n2\tJane\n", true)), csv.Options{Detect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Len() != 2 || table.Value(1, "col1") != "Jane" {
+		t.Errorf("Unexpected table without header %q %q", table.Headers, table.Rows)
+	}
+}
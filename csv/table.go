@@ -0,0 +1,78 @@
+package csv
+
+// Table is parsed CSV data: the header names, in their order in the
+// data, and the rows of values.
+//
+// Header names may be repeated: all the columns are kept, and lookups
+// by name return the first column with that name.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// NewTable returns a table with the specified headers and rows.
+func NewTable(headers []string, rows [][]string) *Table {
+	return &Table{
+		Headers: headers,
+		Rows:    rows,
+	}
+}
+
+// Len returns the number of rows.
+func (t *Table) Len() int {
+	return len(t.Rows)
+}
+
+// Index returns the index of the first column named `name`, or -1 if
+// there is none.
+func (t *Table) Index(name string) int {
+	for i, header := range t.Headers {
+		if header == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Indexes returns the indexes of all the columns named `name`.
+func (t *Table) Indexes(name string) []int {
+	indexes := make([]int, 0)
+	for i, header := range t.Headers {
+		if header == name {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Column returns the values of the first column named `name`, or nil
+// if there is none.
+func (t *Table) Column(name string) []string {
+	i := t.Index(name)
+	if i < 0 {
+		return nil
+	}
+	return t.ColumnAt(i)
+}
+
+// ColumnAt returns the values of the column at index `i`. Rows missing
+// the column have an empty value.
+func (t *Table) ColumnAt(i int) []string {
+	column := make([]string, len(t.Rows))
+	for rowIdx, row := range t.Rows {
+		if i < len(row) {
+			column[rowIdx] = row[i]
+		}
+	}
+	return column
+}
+
+// Value returns the value of the first column named `name` at row
+// `rowIdx`, or an empty string if there is none.
+func (t *Table) Value(rowIdx int, name string) string {
+	i := t.Index(name)
+	if i < 0 || rowIdx < 0 || rowIdx >= len(t.Rows) || i >= len(t.Rows[rowIdx]) {
+		return ""
+	}
+	return t.Rows[rowIdx][i]
+}
@@ -0,0 +1,44 @@
+package csv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golib/csv"
+)
+
+func TestTableDuplicateHeaders(t *testing.T) {
+	data := "id,name,id\n1,John,a\n2,Jane\n"
+	table, err := csv.ParseColumnsFrom(strings.NewReader(data), csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(table.Headers, ",") != "id,name,id" {
+		t.Errorf("Expected all headers to be kept in order, got %v", table.Headers)
+	}
+	if table.Index("id") != 0 || table.Index("nope") != -1 {
+		t.Errorf("Unexpected indexes %d and %d", table.Index("id"), table.Index("nope"))
+	}
+	indexes := table.Indexes("id")
+	if len(indexes) != 2 || indexes[1] != 2 {
+		t.Errorf("Expected `id` columns at 0 and 2, got %v", indexes)
+	}
+	if column := table.ColumnAt(2); column[0] != "a" || column[1] != "" {
+		t.Errorf("Unexpected duplicate column %q", column)
+	}
+	if table.Column("nope") != nil {
+		t.Errorf("Expected no column for an unknown header")
+	}
+	if table.Value(1, "name") != "Jane" || table.Value(5, "name") != "" || table.Len() != 2 {
+		t.Errorf("Unexpected values in table %v", table.Rows)
+	}
+
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf, csv.WriterOptions{LineEnding: "\n"}).WriteTable(table); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "id,name,id\n1,John,a\n2,Jane\n" {
+		t.Errorf("Unexpected table written `%q`", buf.String())
+	}
+}
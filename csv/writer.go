@@ -0,0 +1,253 @@
+package csv
+
+import (
+	"bufio"
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriterOptions configures the writing of CSV data.
+type WriterOptions struct {
+	// Separator separates the fields of a record (defaults to ",").
+	Separator string
+	// LineEnding ends each record (defaults to "\r\n", as specified
+	// by RFC 4180).
+	LineEnding string
+	// BOM writes a UTF-8 byte order mark before the data, for tools
+	// (such as spreadsheets) relying on it to detect the encoding.
+	BOM bool
+	// TimeLayout is the layout used to encode `time.Time` values when
+	// marshalling structs (defaults to `time.RFC3339Nano`). Use
+	// `EpochMilliseconds` for milliseconds since the epoch.
+	TimeLayout string
+}
+
+const utf8BOM = "\xef\xbb\xbf"
+
+// Writer writes records as RFC 4180 CSV data: fields containing the
+// separator, double quotes or line breaks are enclosed in double quotes,
+// and double quotes are escaped by doubling them.
+//
+// Writes are buffered, `Flush` must be called once done.
+type Writer struct {
+	w       *bufio.Writer
+	opts    WriterOptions
+	started bool
+}
+
+// NewWriter returns a writer writing CSV data to `w`.
+func NewWriter(w io.Writer, opts WriterOptions) *Writer {
+	if len(opts.Separator) == 0 {
+		opts.Separator = ","
+	}
+	if len(opts.LineEnding) == 0 {
+		opts.LineEnding = "\r\n"
+	}
+	if len(opts.TimeLayout) == 0 {
+		opts.TimeLayout = time.RFC3339Nano
+	}
+	return &Writer{
+		w:    bufio.NewWriter(w),
+		opts: opts,
+	}
+}
+
+// Write writes a record.
+func (w *Writer) Write(record []string) error {
+	if !w.started {
+		w.started = true
+		if w.opts.BOM {
+			if _, err := w.w.WriteString(utf8BOM); err != nil {
+				return err
+			}
+		}
+	}
+	for i, field := range record {
+		if i > 0 {
+			if _, err := w.w.WriteString(w.opts.Separator); err != nil {
+				return err
+			}
+		}
+		if _, err := w.w.WriteString(w.quote(field, len(record) == 1)); err != nil {
+			return err
+		}
+	}
+	_, err := w.w.WriteString(w.opts.LineEnding)
+	return err
+}
+
+// WriteAll writes the records and flushes the writer.
+func (w *Writer) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// WriteColumns writes a header with `headers`, then the rows made of
+// the values of these columns in `columns`, and flushes the writer.
+// Columns shorter than the longest one are padded with empty values.
+func (w *Writer) WriteColumns(headers []string, columns map[string][]string) error {
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+	rowCount := 0
+	for _, header := range headers {
+		if len(columns[header]) > rowCount {
+			rowCount = len(columns[header])
+		}
+	}
+	for i := 0; i < rowCount; i++ {
+		record := make([]string, len(headers))
+		for j, header := range headers {
+			if i < len(columns[header]) {
+				record[j] = columns[header][i]
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// WriteTable writes the table's headers and rows (e.g. as returned by
+// `ParseCsvToColumns`), and flushes the writer.
+func (w *Writer) WriteTable(t *Table) error {
+	if err := w.Write(t.Headers); err != nil {
+		return err
+	}
+	return w.WriteAll(t.Rows)
+}
+
+// Flush writes the buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// quote quotes `field` if needed. The only field of a record is quoted
+// if empty, as a blank line would be skipped when parsed.
+func (w *Writer) quote(field string, only bool) string {
+	if only && len(field) == 0 {
+		return `""`
+	}
+	if !strings.Contains(field, w.opts.Separator) && !strings.ContainsAny(field, "\"\r\n") {
+		return field
+	}
+	return `"` + strings.Replace(field, `"`, `""`, -1) + `"`
+}
+
+// Encoder encodes structs as CSV rows, one at a time. The header is
+// written before the first row, with the columns mapped to the struct
+// fields as described in `Decoder`.
+type Encoder struct {
+	w      *Writer
+	fields []structField
+	typ    reflect.Type
+}
+
+// NewEncoder returns an encoder writing CSV data to `w`.
+func NewEncoder(w io.Writer, opts WriterOptions) *Encoder {
+	return &Encoder{w: NewWriter(w, opts)}
+}
+
+// Encode writes the struct `v` (or pointed to by `v`) as a row, preceded
+// by the header for the first row.
+func (e *Encoder) Encode(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("expected a struct, got %T", v)
+	}
+	if e.typ == nil {
+		e.typ = rv.Type()
+		e.fields = structFields(e.typ)
+		headers := make([]string, len(e.fields))
+		for i, f := range e.fields {
+			headers[i] = f.column
+		}
+		if err := e.w.Write(headers); err != nil {
+			return err
+		}
+	} else if rv.Type() != e.typ {
+		return fmt.Errorf("expected a %s, got %T", e.typ, v)
+	}
+
+	record := make([]string, len(e.fields))
+	for i, f := range e.fields {
+		value, err := e.encodeValue(rv.FieldByIndex(f.index))
+		if err != nil {
+			return fmt.Errorf("column `%s`: %v", f.column, err)
+		}
+		record[i] = value
+	}
+	return e.w.Write(record)
+}
+
+// Flush writes the buffered data to the underlying writer.
+func (e *Encoder) Flush() error {
+	return e.w.Flush()
+}
+
+// Marshal writes the slice of structs (or of pointers to structs) `v`
+// as CSV data to `w`, with a header row. See `Decoder` for the mapping
+// of columns to struct fields.
+func Marshal(w io.Writer, v interface{}, opts WriterOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("expected a slice, got %T", v)
+	}
+	e := NewEncoder(w, opts)
+	for i := 0; i < rv.Len(); i++ {
+		if err := e.Encode(rv.Index(i).Interface()); err != nil {
+			return fmt.Errorf("row %d: %v", i, err)
+		}
+	}
+	return e.Flush()
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func (e *Encoder) encodeValue(field reflect.Value) (string, error) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return "", nil
+		}
+		return e.encodeValue(field.Elem())
+	}
+
+	if field.Type() == timeType {
+		t := field.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		if e.w.opts.TimeLayout == EpochMilliseconds {
+			return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10), nil
+		}
+		return t.Format(e.w.opts.TimeLayout), nil
+	}
+	if field.Type().Implements(textMarshalerType) {
+		text, err := field.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	}
+	return "", fmt.Errorf("unsupported field type %s", field.Type())
+}
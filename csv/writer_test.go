@@ -0,0 +1,120 @@
+package csv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"golib/csv"
+)
+
+func TestWriterWriteAll(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf, csv.WriterOptions{})
+	err := w.WriteAll([][]string{
+		{"name", "comment"},
+		{"Doe, John", "say \"hi\""},
+		{"Jane", "multi\nline"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "name,comment\r\n\"Doe, John\",\"say \"\"hi\"\"\"\r\nJane,\"multi\nline\"\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected `%q`, got `%q`", expected, buf.String())
+	}
+
+	// The output is parsed back to the same records
+	_, rows, err := csv.ParseRowsFrom(&buf, csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0][0] != "Doe, John" || rows[0][1] != "say \"hi\"" || rows[1][1] != "multi\nline" {
+		t.Errorf("Unexpected rows parsed back %q", rows)
+	}
+}
+
+func TestWriterEmptySingleField(t *testing.T) {
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf, csv.WriterOptions{}).WriteAll([][]string{{"name"}, {""}, {"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "name\r\n\"\"\r\nx\r\n"; buf.String() != expected {
+		t.Errorf("Expected `%q`, got `%q`", expected, buf.String())
+	}
+
+	// The empty value is parsed back instead of skipped as a blank line
+	_, rows, err := csv.ParseRowsFrom(&buf, csv.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchRows(t, [][]string{{""}, {"x"}}, rows)
+}
+
+func TestWriterOptions(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf, csv.WriterOptions{Separator: ";", LineEnding: "\n", BOM: true})
+	if err := w.WriteAll([][]string{{"a;b", "c,d"}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\xef\xbb\xbf\"a;b\";c,d\n"
+	if buf.String() != expected {
+		t.Errorf("Expected `%q`, got `%q`", expected, buf.String())
+	}
+}
+
+func TestWriterWriteColumns(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf, csv.WriterOptions{LineEnding: "\n"})
+	columns := map[string][]string{
+		"col0": {"r0c0", "r1c0"},
+		"col1": {"r0c1"},
+	}
+	if err := w.WriteColumns([]string{"col1", "col0"}, columns); err != nil {
+		t.Fatal(err)
+	}
+	expected := "col1,col0\nr0c1,r0c0\n,r1c0\n"
+	if buf.String() != expected {
+		t.Errorf("Expected `%q`, got `%q`", expected, buf.String())
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type record struct {
+		Name    string     `csv:"name"`
+		Age     int        `csv:"age"`
+		Score   float64    `csv:"score"`
+		Active  bool       `csv:"active"`
+		Created time.Time  `csv:"created"`
+		Deleted *time.Time `csv:"deleted"`
+		Ignored string     `csv:"-"`
+	}
+	created := time.Date(2021, time.April, 30, 10, 0, 0, 0, time.UTC)
+	records := []*record{
+		{Name: "Doe, John", Age: 42, Score: 1.5, Active: true, Created: created},
+		{Name: "Jane", Deleted: &created, Ignored: "x"},
+	}
+
+	var buf bytes.Buffer
+	if err := csv.Marshal(&buf, records, csv.WriterOptions{LineEnding: "\n", TimeLayout: "2006-01-02"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"name,age,score,active,created,deleted",
+		"\"Doe, John\",42,1.5,true,2021-04-30,",
+		"Jane,0,0,false,,2021-04-30",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	var decoded []record
+	if err := csv.NewDecoder(&buf, csv.Options{TimeLayouts: []string{"2006-01-02"}}).DecodeAll(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 2 || decoded[0].Name != "Doe, John" || decoded[1].Deleted == nil || !decoded[1].Deleted.Equal(created.Truncate(24*time.Hour)) {
+		t.Errorf("Unexpected records decoded back %+v", decoded)
+	}
+}
@@ -0,0 +1,194 @@
+package s3
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	casBlobsDir     = "blobs/"
+	casManifestFile = "manifest.json"
+)
+
+// DefaultGracePeriod is the default age under which `GC` keeps
+// unreferenced blobs.
+const DefaultGracePeriod = time.Hour
+
+// ContentStore is a content-addressed store built on top of `S3`.
+//
+// Blobs are stored under `<prefix>blobs/<sha256>`, so identical contents
+// are only uploaded once. A manifest stored at `<prefix>manifest.json`
+// maps logical names to the hashes of their contents.
+//
+// ### NB: limitations
+//
+//   - The manifest is updated with a read-modify-write cycle, so concurrent
+//     writers on the same prefix may lose updates.
+//   - A blob is only referenced once `Put` has written the manifest. `GC`
+//     keeps the blobs uploaded or reused by `PutBlob` within the grace
+//     period, so a `Put` is only at risk of referencing a deleted blob if
+//     it takes longer than the grace period, or if it reuses a blob
+//     between the listing of the blobs by a `GC` and their deletion.
+//
+type ContentStore struct {
+	S3     S3
+	Prefix string
+	// GracePeriod is the age under which `GC` keeps unreferenced blobs,
+	// covering the `Put` calls writing the manifest (0 disables it).
+	GracePeriod time.Duration
+}
+
+// NewContentStore returns a `ContentStore` storing its blobs and
+// manifest in the specified S3 bucket under `prefix`, with the
+// `DefaultGracePeriod`.
+func NewContentStore(s3 S3, prefix string) ContentStore {
+	return ContentStore{
+		S3:          s3,
+		Prefix:      prefix,
+		GracePeriod: DefaultGracePeriod,
+	}
+}
+
+// Hash returns the SHA-256 hex digest used to address `content`.
+func Hash(content []byte) string {
+	return checksumSHA256(content)
+}
+
+// BlobKey returns the S3 key of the blob with the specified hash.
+func (cs ContentStore) BlobKey(hash string) string {
+	return cs.Prefix + casBlobsDir + hash
+}
+
+// PutBlob uploads `content` unless a blob with the same hash already
+// exists and returns its hash. An existing blob is touched instead (see
+// `S3.TouchObject`), so `GC` keeps it during the grace period.
+func (cs ContentStore) PutBlob(content []byte) (string, error) {
+	hash := Hash(content)
+	key := cs.BlobKey(hash)
+	exists, err := cs.S3.TouchObject(key)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return hash, nil
+	}
+	if err := cs.S3.CreateObject(key, content); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// FetchBlob fetches the content of the blob with the specified hash.
+func (cs ContentStore) FetchBlob(hash string) ([]byte, error) {
+	return cs.S3.FetchObject(cs.BlobKey(hash))
+}
+
+// Put stores `content` under the logical `name` and returns the hash
+// of the content. The blob is only uploaded if it does not exist yet.
+func (cs ContentStore) Put(name string, content []byte) (string, error) {
+	hash, err := cs.PutBlob(content)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := cs.Manifest()
+	if err != nil {
+		return "", err
+	}
+	if manifest[name] == hash {
+		return hash, nil
+	}
+	manifest[name] = hash
+	if err := cs.writeManifest(manifest); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// Fetch fetches the content stored under the logical `name`.
+func (cs ContentStore) Fetch(name string) ([]byte, error) {
+	manifest, err := cs.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := manifest[name]
+	if !ok {
+		return nil, fmt.Errorf("no content stored under `%s`", name)
+	}
+	return cs.FetchBlob(hash)
+}
+
+// Remove removes the logical `name` from the manifest. The blob itself
+// is left in place until `GC` is run.
+func (cs ContentStore) Remove(name string) error {
+	manifest, err := cs.Manifest()
+	if err != nil {
+		return err
+	}
+	if _, ok := manifest[name]; !ok {
+		return nil
+	}
+	delete(manifest, name)
+	return cs.writeManifest(manifest)
+}
+
+// Manifest returns the mapping of logical names to hashes. An empty
+// map is returned if no manifest has been written yet.
+func (cs ContentStore) Manifest() (map[string]string, error) {
+	manifest := make(map[string]string)
+	key := cs.Prefix + casManifestFile
+	exists, err := cs.S3.ObjectExists(key)
+	if err != nil || !exists {
+		return manifest, err
+	}
+	content, err := cs.S3.FetchObject(key)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to decode manifest, %v", err)
+	}
+	return manifest, nil
+}
+
+// GC deletes the blobs that are not referenced by the manifest anymore
+// and are older than the grace period, and returns the hashes of the
+// deleted blobs.
+func (cs ContentStore) GC() ([]string, error) {
+	deleted := make([]string, 0)
+	manifest, err := cs.Manifest()
+	if err != nil {
+		return deleted, err
+	}
+	referenced := make(map[string]bool)
+	for _, hash := range manifest {
+		referenced[hash] = true
+	}
+
+	blobs, err := cs.S3.ListObjectsInfo(cs.Prefix + casBlobsDir)
+	if err != nil {
+		return deleted, err
+	}
+	for _, blob := range blobs {
+		hash := strings.TrimPrefix(blob.Key, cs.Prefix+casBlobsDir)
+		if referenced[hash] || time.Since(blob.LastModified) < cs.GracePeriod {
+			continue
+		}
+		if err := cs.S3.DeleteObject(blob.Key); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, hash)
+	}
+	sort.Strings(deleted)
+	return deleted, nil
+}
+
+func (cs ContentStore) writeManifest(manifest map[string]string) error {
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest, %v", err)
+	}
+	return cs.S3.CreateObject(cs.Prefix+casManifestFile, content)
+}
@@ -0,0 +1,102 @@
+package s3_test
+
+import (
+	"testing"
+	"time"
+
+	s3lib "golib/s3"
+)
+
+func TestContentStoreBlobKey(t *testing.T) {
+	cs := s3lib.NewContentStore(s3lib.NewS3("bucket"), "cas/")
+	hash := s3lib.Hash([]byte("hello"))
+	expectedHash := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if hash != expectedHash {
+		t.Errorf("expected hash `%s`, got `%s`", expectedHash, hash)
+	}
+	expectedKey := "cas/blobs/" + expectedHash
+	if key := cs.BlobKey(hash); key != expectedKey {
+		t.Errorf("expected blob key `%s`, got `%s`", expectedKey, key)
+	}
+}
+
+func TestContentStorePutFetchAndGC(t *testing.T) {
+	client := newTestS3(t)
+	cs := s3lib.NewContentStore(client, "test_cas/")
+	cs.GracePeriod = 0
+
+	hashA, err := cs.Put("a", []byte("same content"))
+	handleError(err, t)
+	hashB, err := cs.Put("b", []byte("same content"))
+	handleError(err, t)
+	if hashA != hashB {
+		t.Errorf("expected identical contents to share a hash, got `%s` and `%s`", hashA, hashB)
+	}
+	blobKeys, err := client.ListObjects("test_cas/blobs/")
+	handleError(err, t)
+	if len(blobKeys) != 1 {
+		t.Errorf("expected 1 blob to be stored, got %d", len(blobKeys))
+	}
+
+	content, err := cs.Fetch("b")
+	handleError(err, t)
+	if string(content) != "same content" {
+		t.Errorf("expected fetched content to be `same content`, got `%s`", content)
+	}
+
+	handleError(cs.Remove("a"), t)
+	deleted, err := cs.GC()
+	handleError(err, t)
+	if len(deleted) != 0 {
+		t.Errorf("expected no blob to be collected while still referenced, got %v", deleted)
+	}
+	handleError(cs.Remove("b"), t)
+	deleted, err = cs.GC()
+	handleError(err, t)
+	if len(deleted) != 1 || deleted[0] != hashA {
+		t.Errorf("expected blob `%s` to be collected, got %v", hashA, deleted)
+	}
+
+	handleError(client.DeleteObject("test_cas/manifest.json"), t)
+}
+
+func TestContentStoreGCKeepsBlobsBeingPut(t *testing.T) {
+	client, fake := newFakeS3(t)
+	cs := s3lib.NewContentStore(client, "test_cas/")
+
+	// GC runs between the upload of the blob and the manifest write
+	hash, err := cs.PutBlob([]byte("content"))
+	handleError(err, t)
+	deleted, err := cs.GC()
+	handleError(err, t)
+	if len(deleted) != 0 {
+		t.Errorf("expected a new blob to be kept, got %v deleted", deleted)
+	}
+
+	// Same with an old blob reused by `PutBlob`
+	blob := fake.objects[cs.BlobKey(hash)]
+	blob.lastModified = time.Now().Add(-2 * s3lib.DefaultGracePeriod)
+	_, err = cs.PutBlob([]byte("content"))
+	handleError(err, t)
+	deleted, err = cs.GC()
+	handleError(err, t)
+	if len(deleted) != 0 {
+		t.Errorf("expected a reused blob to be kept, got %v deleted", deleted)
+	}
+	_, err = cs.Put("a", []byte("content"))
+	handleError(err, t)
+	content, err := cs.Fetch("a")
+	handleError(err, t)
+	if string(content) != "content" {
+		t.Errorf("expected fetched content to be `content`, got `%s`", content)
+	}
+
+	// Unreferenced blobs are collected once older than the grace period
+	handleError(cs.Remove("a"), t)
+	fake.objects[cs.BlobKey(hash)].lastModified = time.Now().Add(-2 * s3lib.DefaultGracePeriod)
+	deleted, err = cs.GC()
+	handleError(err, t)
+	if len(deleted) != 1 || deleted[0] != hash {
+		t.Errorf("expected blob `%s` to be collected, got %v", hash, deleted)
+	}
+}
@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// checksumMetadataKey is the user-defined metadata key (sent as
+// `x-amz-meta-sha256`) storing the SHA-256 of an object's content.
+const checksumMetadataKey = "sha256"
+
+// ChecksumMismatchError is returned when the content fetched for an
+// object does not match the checksum stored with it.
+type ChecksumMismatchError struct {
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for object `%s`: expected sha256 `%s`, got `%s`", e.Key, e.Expected, e.Actual)
+}
+
+func contentMD5(content []byte) string {
+	sum := md5.Sum(content)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func checksumSHA256(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyChecksum compares the SHA-256 of `content` with the one stored
+// in the object's `metadata`, if any.
+func verifyChecksum(key string, content []byte, metadata map[string]*string) error {
+	return verifySum(key, checksumSHA256(content), metadata)
+}
+
+// verifySum compares the hex-encoded SHA-256 `actual` with the one
+// stored in the object's `metadata`, if any.
+//
+// The SDK canonicalizes metadata keys as HTTP headers (e.g. `Sha256`),
+// so the lookup is case-insensitive.
+func verifySum(key string, actual string, metadata map[string]*string) error {
+	for k, v := range metadata {
+		if !strings.EqualFold(k, checksumMetadataKey) || v == nil {
+			continue
+		}
+		if !strings.EqualFold(*v, actual) {
+			return &ChecksumMismatchError{Key: key, Expected: *v, Actual: actual}
+		}
+		return nil
+	}
+	return nil
+}
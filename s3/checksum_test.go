@@ -0,0 +1,81 @@
+package s3_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	s3lib "golib/s3"
+)
+
+func TestChecksumMismatchError(t *testing.T) {
+	var err error = &s3lib.ChecksumMismatchError{Key: "key", Expected: "abc", Actual: "def"}
+	expected := "checksum mismatch for object `key`: expected sha256 `abc`, got `def`"
+	if err.Error() != expected {
+		t.Errorf("expected error `%s`, got `%s`", expected, err.Error())
+	}
+}
+
+func TestCreateAndFetchWithChecksum(t *testing.T) {
+	client := newTestS3(t)
+	key := "test_checksum"
+	handleError(client.CreateObject(key, []byte("checksummed content")), t)
+	content, err := client.FetchObject(key)
+	handleError(err, t)
+	if string(content) != "checksummed content" {
+		t.Errorf("expected fetched content to be `checksummed content`, got `%s`", content)
+	}
+	handleError(client.DeleteObject(key), t)
+}
+
+func TestFetchCorruptedObject(t *testing.T) {
+	client, fake := newFakeS3(t)
+	key := "test_checksum"
+	handleError(client.CreateObject(key, []byte("checksummed content")), t)
+	fake.objects[key].content = []byte("corrupted content")
+
+	_, err := client.FetchObject(key)
+	mismatch, ok := err.(*s3lib.ChecksumMismatchError)
+	if !ok {
+		t.Fatalf("expected a checksum mismatch error, got `%v`", err)
+	}
+	if mismatch.Key != key {
+		t.Errorf("expected mismatch on key `%s`, got `%s`", key, mismatch.Key)
+	}
+}
+
+// onlyReader hides the other methods of a reader, such as `Seek`.
+type onlyReader struct {
+	io.Reader
+}
+
+func TestCreateFromAndFetchToWithChecksum(t *testing.T) {
+	client, fake := newFakeS3(t)
+	readers := map[string]io.Reader{
+		"seekable":     strings.NewReader("streamed content"),
+		"non-seekable": onlyReader{strings.NewReader("streamed content")},
+	}
+	for name, r := range readers {
+		key := "test_stream_" + name
+		n, err := client.CreateObjectFrom(key, r)
+		handleError(err, t)
+		if n != int64(len("streamed content")) {
+			t.Errorf("%s: expected %d bytes uploaded, got %d", name, len("streamed content"), n)
+		}
+
+		var fetched bytes.Buffer
+		_, err = client.FetchObjectTo(key, &fetched)
+		handleError(err, t)
+		if fetched.String() != "streamed content" {
+			t.Errorf("%s: expected fetched content to be `streamed content`, got `%s`", name, fetched.String())
+		}
+
+		fake.objects[key].content = []byte("corrupted content")
+		fetched.Reset()
+		_, err = client.FetchObjectTo(key, &fetched)
+		if _, ok := err.(*s3lib.ChecksumMismatchError); !ok {
+			t.Errorf("%s: expected a checksum mismatch error, got `%v`", name, err)
+		}
+	}
+}
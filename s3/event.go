@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Event is an S3 event notification.
+//
+// See https://docs.aws.amazon.com/AmazonS3/latest/userguide/notification-content-structure.html
+type Event struct {
+	Records []EventRecord `json:"Records"`
+}
+
+// EventRecord is a record of an S3 event notification.
+type EventRecord struct {
+	EventVersion string    `json:"eventVersion"`
+	EventSource  string    `json:"eventSource"`
+	AWSRegion    string    `json:"awsRegion"`
+	EventTime    time.Time `json:"eventTime"`
+	// EventName is the type of event (e.g. "ObjectCreated:Put").
+	EventName string      `json:"eventName"`
+	S3        EventEntity `json:"s3"`
+}
+
+// EventEntity describes the bucket and object an event record is about.
+type EventEntity struct {
+	SchemaVersion   string      `json:"s3SchemaVersion"`
+	ConfigurationID string      `json:"configurationId"`
+	Bucket          EventBucket `json:"bucket"`
+	Object          EventObject `json:"object"`
+}
+
+// EventBucket is the bucket of an event record.
+type EventBucket struct {
+	Name string `json:"name"`
+	ARN  string `json:"arn"`
+}
+
+// EventObject is the object of an event record. Its key is URL-encoded,
+// use `EventRecord.ObjectRef` to get the decoded key.
+type EventObject struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size"`
+	ETag      string `json:"eTag"`
+	VersionID string `json:"versionId"`
+	Sequencer string `json:"sequencer"`
+}
+
+// ObjectRef references an object an event is about, ready to be
+// used with the wrapper (e.g. `NewS3(ref.Bucket).FetchObject(ref.Key)`).
+type ObjectRef struct {
+	Bucket    string
+	Key       string
+	EventName string
+	EventTime time.Time
+	Size      int64
+	VersionID string
+}
+
+// IsObjectCreated returns true for `ObjectCreated:*` events.
+func (r EventRecord) IsObjectCreated() bool {
+	return strings.HasPrefix(r.EventName, "ObjectCreated:")
+}
+
+// IsObjectRemoved returns true for `ObjectRemoved:*` events.
+func (r EventRecord) IsObjectRemoved() bool {
+	return strings.HasPrefix(r.EventName, "ObjectRemoved:")
+}
+
+// ObjectRef returns the reference to the record's object, with its key
+// URL-decoded.
+func (r EventRecord) ObjectRef() (ObjectRef, error) {
+	key, err := url.QueryUnescape(r.S3.Object.Key)
+	if err != nil {
+		return ObjectRef{}, fmt.Errorf("failed to decode object key `%s`: %v", r.S3.Object.Key, err)
+	}
+	return ObjectRef{
+		Bucket:    r.S3.Bucket.Name,
+		Key:       key,
+		EventName: r.EventName,
+		EventTime: r.EventTime,
+		Size:      r.S3.Object.Size,
+		VersionID: r.S3.Object.VersionID,
+	}, nil
+}
+
+// ParseEvent parses an S3 event notification and returns the references
+// to the objects of its records.
+//
+// The notification may be wrapped in SNS and/or SQS envelopes, either
+// as delivered to queue consumers (an SNS notification or SQS message
+// body) or to Lambda functions (records with an `Sns` or `body` field).
+// `s3:TestEvent` notifications have no records and return no references.
+func ParseEvent(data []byte) ([]ObjectRef, error) {
+	refs := make([]ObjectRef, 0)
+	var envelope struct {
+		Records []json.RawMessage `json:"Records"`
+		Type    string            `json:"Type"`
+		Message *string           `json:"Message"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return refs, fmt.Errorf("failed to decode event: %v", err)
+	}
+
+	// SNS notification
+	if envelope.Type == "Notification" && envelope.Message != nil {
+		return ParseEvent([]byte(*envelope.Message))
+	}
+
+	for _, rawRecord := range envelope.Records {
+		recordRefs, err := parseEventRecord(rawRecord)
+		if err != nil {
+			return refs, err
+		}
+		refs = append(refs, recordRefs...)
+	}
+	return refs, nil
+}
+
+func parseEventRecord(rawRecord json.RawMessage) ([]ObjectRef, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rawRecord, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode event record: %v", err)
+	}
+
+	// SNS record, as delivered to Lambda functions
+	if rawSNS, ok := fields["Sns"]; ok {
+		var sns struct {
+			Message string `json:"Message"`
+		}
+		if err := json.Unmarshal(rawSNS, &sns); err != nil {
+			return nil, fmt.Errorf("failed to decode SNS record: %v", err)
+		}
+		return ParseEvent([]byte(sns.Message))
+	}
+
+	// SQS record, as delivered to Lambda functions
+	if rawBody, ok := fields["body"]; ok {
+		var body string
+		if err := json.Unmarshal(rawBody, &body); err != nil {
+			return nil, fmt.Errorf("failed to decode SQS record: %v", err)
+		}
+		return ParseEvent([]byte(body))
+	}
+
+	var record EventRecord
+	if err := json.Unmarshal(rawRecord, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode S3 event record: %v", err)
+	}
+	ref, err := record.ObjectRef()
+	if err != nil {
+		return nil, err
+	}
+	return []ObjectRef{ref}, nil
+}
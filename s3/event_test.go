@@ -0,0 +1,72 @@
+package s3_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	s3lib "golib/s3"
+)
+
+const s3EventJSON = `{"Records":[
+	{"eventVersion":"2.1","eventSource":"aws:s3","awsRegion":"eu-west-3","eventTime":"2021-04-30T10:00:00.000Z",
+	 "eventName":"ObjectCreated:Put","s3":{"s3SchemaVersion":"1.0","configurationId":"cfg",
+	 "bucket":{"name":"my-bucket","arn":"arn:aws:s3:::my-bucket"},
+	 "object":{"key":"exports/2021%2F04/my+file%C3%A9.csv","size":1024,"eTag":"abc","sequencer":"0A"}}},
+	{"eventVersion":"2.1","eventSource":"aws:s3","awsRegion":"eu-west-3","eventTime":"2021-04-30T10:01:00.000Z",
+	 "eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"my-bucket"},"object":{"key":"old.csv"}}}
+]}`
+
+func quoteJSON(t *testing.T, s string) string {
+	b, err := json.Marshal(s)
+	handleError(err, t)
+	return string(b)
+}
+
+func TestParseEvent(t *testing.T) {
+	snsEnvelope := `{"Type":"Notification","MessageId":"1","TopicArn":"arn:aws:sns:eu-west-3:1:topic","Message":` + quoteJSON(t, s3EventJSON) + `}`
+	cases := map[string]string{
+		"s3":         s3EventJSON,
+		"sns":        snsEnvelope,
+		"lambda_sns": `{"Records":[{"EventSource":"aws:sns","Sns":{"Type":"Notification","Message":` + quoteJSON(t, s3EventJSON) + `}}]}`,
+		"lambda_sqs": `{"Records":[{"messageId":"1","eventSource":"aws:sqs","body":` + quoteJSON(t, s3EventJSON) + `}]}`,
+		"sqs_sns":    `{"Records":[{"messageId":"1","eventSource":"aws:sqs","body":` + quoteJSON(t, snsEnvelope) + `}]}`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			refs, err := s3lib.ParseEvent([]byte(data))
+			handleError(err, t)
+			if len(refs) != 2 {
+				t.Fatalf("expected 2 object references, got %d", len(refs))
+			}
+			if refs[0].Bucket != "my-bucket" || refs[0].Key != "exports/2021/04/my fileé.csv" || refs[0].Size != 1024 {
+				t.Errorf("unexpected first reference %+v", refs[0])
+			}
+			if refs[1].EventName != "ObjectRemoved:Delete" || refs[1].Key != "old.csv" {
+				t.Errorf("unexpected second reference %+v", refs[1])
+			}
+		})
+	}
+}
+
+func TestParseTestEvent(t *testing.T) {
+	data := `{"Service":"Amazon S3","Event":"s3:TestEvent","Time":"2021-04-30T10:00:00.000Z","Bucket":"my-bucket"}`
+	refs, err := s3lib.ParseEvent([]byte(data))
+	handleError(err, t)
+	if len(refs) != 0 {
+		t.Errorf("expected no object reference for a test event, got %v", refs)
+	}
+}
+
+func TestEventRecordTypes(t *testing.T) {
+	var event s3lib.Event
+	handleError(json.Unmarshal([]byte(s3EventJSON), &event), t)
+	if !event.Records[0].IsObjectCreated() || event.Records[0].IsObjectRemoved() {
+		t.Errorf("expected first record to be an object creation")
+	}
+	if !event.Records[1].IsObjectRemoved() {
+		t.Errorf("expected second record to be an object removal")
+	}
+	if _, err := s3lib.ParseEvent([]byte("not json")); err == nil {
+		t.Errorf("expected an error for an invalid event")
+	}
+}
@@ -0,0 +1,285 @@
+package s3_test
+
+// In-memory fake of the subset of the S3 API used by the wrapper, so
+// tests can run without an AWS account. Only path-style requests on a
+// single bucket are supported.
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	s3lib "golib/s3"
+)
+
+type fakeObject struct {
+	content      []byte
+	etag         string
+	metadata     http.Header
+	lastModified time.Time
+	storageClass string
+}
+
+type fakeS3 struct {
+	bucket  string
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+}
+
+// newTestS3 returns an S3 client for the tests. It targets an in-memory
+// fake unless `RUN_S3_E2E_TESTING` is `true`, in which case the bucket
+// (e.g. on AWS or MinIO) configured through the `AWS_` environment
+// variables is used.
+func newTestS3(t *testing.T) s3lib.S3 {
+	if os.Getenv("RUN_S3_E2E_TESTING") == "true" {
+		profile, err := s3lib.ProfileFromEnv("AWS_")
+		handleError(err, t)
+		client, err := s3lib.NewS3FromProfile(profile)
+		handleError(err, t)
+		return client
+	}
+	client, _ := newFakeS3(t)
+	return client
+}
+
+// newFakeS3 starts an in-memory fake S3 server, stopped at the end of
+// the test, and returns a client targeting it.
+func newFakeS3(t *testing.T) (s3lib.S3, *fakeS3) {
+	fake := &fakeS3{bucket: "fake-bucket", objects: make(map[string]*fakeObject)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := s3lib.NewS3FromProfile(s3lib.Profile{
+		Bucket:            fake.bucket,
+		Endpoint:          server.URL,
+		Region:            "us-east-1",
+		CredentialsSource: s3lib.CredentialsStatic,
+		AccessKeyID:       "key",
+		SecretAccessKey:   "secret",
+		ForcePathStyle:    true,
+	})
+	handleError(err, t)
+	return client, fake
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path != f.bucket && !strings.HasPrefix(path, f.bucket+"/") {
+		f.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(path, f.bucket), "/")
+	if len(key) == 0 {
+		if r.Method == http.MethodGet {
+			f.list(w, r)
+			return
+		}
+		f.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+		return
+	}
+
+	obj := f.objects[key]
+	if !f.checkConditions(w, r, obj) {
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		f.put(w, r, key)
+	case http.MethodGet, http.MethodHead:
+		if obj == nil {
+			f.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		for k, v := range obj.metadata {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", obj.etag)
+		w.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.content)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(obj.content)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) checkConditions(w http.ResponseWriter, r *http.Request, obj *fakeObject) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); len(ifNoneMatch) > 0 && r.Method == http.MethodPut {
+		if obj != nil && (ifNoneMatch == "*" || ifNoneMatch == obj.etag) {
+			f.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return false
+		}
+	}
+	if ifMatch := r.Header.Get("If-Match"); len(ifMatch) > 0 {
+		if obj == nil {
+			f.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return false
+		}
+		if ifMatch != obj.etag {
+			f.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeS3) put(w http.ResponseWriter, r *http.Request, key string) {
+	if len(r.Header.Get("X-Amz-Copy-Source")) > 0 {
+		f.copy(w, r, key)
+		return
+	}
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		f.writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	sum := md5.Sum(content)
+	if contentMD5 := r.Header.Get("Content-MD5"); len(contentMD5) > 0 && contentMD5 != base64.StdEncoding.EncodeToString(sum[:]) {
+		f.writeError(w, http.StatusBadRequest, "BadDigest")
+		return
+	}
+	metadata := make(http.Header)
+	for k, v := range r.Header {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+			metadata[k] = v
+		}
+	}
+	storageClass := r.Header.Get("X-Amz-Storage-Class")
+	if len(storageClass) == 0 {
+		storageClass = "STANDARD"
+	}
+	obj := &fakeObject{
+		content:      content,
+		etag:         fmt.Sprintf("\"%s\"", hex.EncodeToString(sum[:])),
+		metadata:     metadata,
+		lastModified: time.Now().UTC(),
+		storageClass: storageClass,
+	}
+	f.objects[key] = obj
+	w.Header().Set("ETag", obj.etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// copy copies an object within the bucket, replacing its metadata with
+// the request's if asked to.
+func (f *fakeS3) copy(w http.ResponseWriter, r *http.Request, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		f.writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	src := f.objects[strings.TrimPrefix(strings.TrimPrefix(source, "/"), f.bucket+"/")]
+	if src == nil {
+		f.writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	metadata := src.metadata
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		metadata = make(http.Header)
+		for k, v := range r.Header {
+			if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+				metadata[k] = v
+			}
+		}
+	}
+	obj := &fakeObject{
+		content:      src.content,
+		etag:         src.etag,
+		metadata:     metadata,
+		lastModified: time.Now().UTC(),
+		storageClass: src.storageClass,
+	}
+	f.objects[key] = obj
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "<CopyObjectResult><ETag>%s</ETag><LastModified>%s</LastModified></CopyObjectResult>",
+		obj.etag, obj.lastModified.Format(time.RFC3339Nano))
+}
+
+type fakeListContent struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	StorageClass string
+}
+
+type fakeListPrefix struct {
+	Prefix string
+}
+
+type fakeListResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string
+	Prefix         string
+	Delimiter      string
+	IsTruncated    bool
+	Contents       []fakeListContent
+	CommonPrefixes []fakeListPrefix
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	delimiter := r.URL.Query().Get("delimiter")
+	result := fakeListResult{Name: f.bucket, Prefix: prefix, Delimiter: delimiter}
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	seenPrefixes := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if len(delimiter) > 0 {
+			if idx := strings.Index(key[len(prefix):], delimiter); idx >= 0 {
+				commonPrefix := key[:len(prefix)+idx+len(delimiter)]
+				if !seenPrefixes[commonPrefix] {
+					seenPrefixes[commonPrefix] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, fakeListPrefix{commonPrefix})
+				}
+				continue
+			}
+		}
+		obj := f.objects[key]
+		result.Contents = append(result.Contents, fakeListContent{
+			Key:          key,
+			LastModified: obj.lastModified.Format(time.RFC3339Nano),
+			ETag:         obj.etag,
+			Size:         len(obj.content),
+			StorageClass: obj.storageClass,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	xml.NewEncoder(w).Encode(result)
+}
+
+func (f *fakeS3) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
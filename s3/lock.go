@@ -0,0 +1,253 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awsS3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+var (
+	// ErrLockHeld is returned when acquiring a lock currently held by
+	// another owner.
+	ErrLockHeld = errors.New("lock is held by another owner")
+	// ErrLockLost is returned when renewing or releasing a lock which
+	// is not held anymore (e.g. its lease expired and it was taken over).
+	ErrLockLost = errors.New("lock is not held anymore")
+
+	errPreconditionFailed = errors.New("precondition failed")
+	errObjectNotFound     = errors.New("object not found")
+)
+
+// Lock is a lease-based distributed mutex on an S3 key.
+//
+// The lock object stores its owner and the expiry of its lease. It is
+// created with a conditional write (`If-None-Match: *`), so only one
+// owner can acquire it. Once the lease has expired, the lock may be
+// taken over by another owner. Renewals and releases are conditioned
+// on the lock object's ETag (`If-Match`), so they fail with `ErrLockLost`
+// if the lock has been taken over in the meantime.
+//
+// ### NB: limitations
+//
+//   - The backend must support conditional writes (AWS S3 does, some
+//     S3-compatible providers don't).
+//   - Lease expiry relies on the owners' clocks being reasonably in sync.
+//
+type Lock struct {
+	S3    S3
+	Key   string
+	Owner string
+	TTL   time.Duration
+
+	etag string
+}
+
+type lease struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewLock returns a lock on `key` for `owner`, whose leases last `ttl`.
+func NewLock(s3 S3, key string, owner string, ttl time.Duration) *Lock {
+	return &Lock{
+		S3:    s3,
+		Key:   key,
+		Owner: owner,
+		TTL:   ttl,
+	}
+}
+
+// Acquire acquires the lock, returning `ErrLockHeld` if another owner
+// holds a lease which has not expired yet. It does not wait for the
+// lock to be released.
+func (l *Lock) Acquire() error {
+	content, err := l.newLease()
+	if err != nil {
+		return err
+	}
+	etag, err := l.S3.putObjectIf(l.Key, content, "", "*")
+	if err == nil {
+		l.etag = etag
+		return nil
+	}
+	if err != errPreconditionFailed {
+		return err
+	}
+
+	// The lock object exists, it may be taken over if expired.
+	current, currentETag, err := l.S3.fetchObjectWithETag(l.Key)
+	if err == errObjectNotFound {
+		return ErrLockHeld
+	}
+	if err != nil {
+		return err
+	}
+	var currentLease lease
+	if err := json.Unmarshal(current, &currentLease); err != nil {
+		return fmt.Errorf("failed to decode lock `%s`, %v", l.Key, err)
+	}
+	if currentLease.Owner != l.Owner && time.Now().Before(currentLease.ExpiresAt) {
+		return ErrLockHeld
+	}
+	etag, err = l.S3.putObjectIf(l.Key, content, currentETag, "")
+	if err == errPreconditionFailed || err == errObjectNotFound {
+		return ErrLockHeld
+	}
+	if err != nil {
+		return err
+	}
+	l.etag = etag
+	return nil
+}
+
+// Renew extends the lease of a held lock by the lock's TTL.
+func (l *Lock) Renew() error {
+	if len(l.etag) == 0 {
+		return ErrLockLost
+	}
+	content, err := l.newLease()
+	if err != nil {
+		return err
+	}
+	etag, err := l.S3.putObjectIf(l.Key, content, l.etag, "")
+	if err == errPreconditionFailed || err == errObjectNotFound {
+		l.etag = ""
+		return ErrLockLost
+	}
+	if err != nil {
+		return err
+	}
+	l.etag = etag
+	return nil
+}
+
+// Release releases a held lock by deleting the lock object.
+func (l *Lock) Release() error {
+	if len(l.etag) == 0 {
+		return ErrLockLost
+	}
+	err := l.S3.deleteObjectIf(l.Key, l.etag)
+	l.etag = ""
+	if err == errPreconditionFailed || err == errObjectNotFound {
+		return ErrLockLost
+	}
+	return err
+}
+
+func (l *Lock) newLease() ([]byte, error) {
+	content, err := json.Marshal(lease{
+		Owner:     l.Owner,
+		ExpiresAt: time.Now().Add(l.TTL).UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lock lease, %v", err)
+	}
+	return content, nil
+}
+
+// putObjectIf creates or replaces an object only if the specified
+// `If-Match` and `If-None-Match` conditions (ignored if empty) are met,
+// and returns the new object's ETag.
+func (s3 S3) putObjectIf(key string, content []byte, ifMatch string, ifNoneMatch string) (etag string, err error) {
+	start := time.Now()
+	defer func() { s3.observe("ConditionalCreateObject", key, start, int64(len(content)), err) }()
+
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	req, output := awsS3Client.PutObjectRequest(&awsS3.PutObjectInput{
+		Bucket:     aws.String(s3.Bucket),
+		Key:        aws.String(key),
+		Body:       bytes.NewReader(content),
+		ContentMD5: aws.String(contentMD5(content)),
+		Metadata: map[string]*string{
+			checksumMetadataKey: aws.String(checksumSHA256(content)),
+		},
+	})
+	// The SDK's `PutObjectInput` does not support conditional writes yet.
+	setConditionHeaders(req, ifMatch, ifNoneMatch)
+	if err = req.Send(); err != nil {
+		return "", conditionalRequestError("failed to upload object", err)
+	}
+	return aws.StringValue(output.ETag), nil
+}
+
+// fetchObjectWithETag fetches the content of an object and its ETag.
+func (s3 S3) fetchObjectWithETag(key string) (content []byte, etag string, err error) {
+	start := time.Now()
+	defer func() { s3.observe("FetchObject", key, start, int64(len(content)), err) }()
+
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	output, err := awsS3Client.GetObject(&awsS3.GetObjectInput{
+		Bucket: aws.String(s3.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, "", conditionalRequestError("failed to download object", err)
+	}
+	defer output.Body.Close()
+
+	content, err = ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download object, %v", err)
+	}
+	if err := verifyChecksum(key, content, output.Metadata); err != nil {
+		return content, "", err
+	}
+	return content, aws.StringValue(output.ETag), nil
+}
+
+// deleteObjectIf deletes an object only if its ETag matches `ifMatch`.
+func (s3 S3) deleteObjectIf(key string, ifMatch string) (err error) {
+	start := time.Now()
+	defer func() { s3.observe("ConditionalDeleteObject", key, start, 0, err) }()
+
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	req, _ := awsS3Client.DeleteObjectRequest(&awsS3.DeleteObjectInput{
+		Bucket: aws.String(s3.Bucket),
+		Key:    aws.String(key),
+	})
+	setConditionHeaders(req, ifMatch, "")
+	if err = req.Send(); err != nil {
+		return conditionalRequestError("failed to delete object", err)
+	}
+	return nil
+}
+
+func setConditionHeaders(req *request.Request, ifMatch string, ifNoneMatch string) {
+	req.Handlers.Build.PushBack(func(r *request.Request) {
+		if len(ifMatch) > 0 {
+			r.HTTPRequest.Header.Set("If-Match", ifMatch)
+		}
+		if len(ifNoneMatch) > 0 {
+			r.HTTPRequest.Header.Set("If-None-Match", ifNoneMatch)
+		}
+	})
+}
+
+// conditionalRequestError maps failed preconditions and missing objects
+// to `errPreconditionFailed` and `errObjectNotFound`.
+func conditionalRequestError(msg string, err error) error {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		switch reqErr.StatusCode() {
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			return errPreconditionFailed
+		case http.StatusNotFound:
+			return errObjectNotFound
+		}
+	}
+	return fmt.Errorf("%s, %v", msg, err)
+}
@@ -0,0 +1,58 @@
+package s3_test
+
+import (
+	"testing"
+	"time"
+
+	s3lib "golib/s3"
+)
+
+func TestLockAcquireAndRelease(t *testing.T) {
+	client := newTestS3(t)
+	lockA := s3lib.NewLock(client, "test_lock", "a", time.Minute)
+	lockB := s3lib.NewLock(client, "test_lock", "b", time.Minute)
+
+	handleError(lockA.Acquire(), t)
+	if err := lockB.Acquire(); err != s3lib.ErrLockHeld {
+		t.Errorf("expected `%v` while the lock is held, got `%v`", s3lib.ErrLockHeld, err)
+	}
+	handleError(lockA.Renew(), t)
+	handleError(lockA.Release(), t)
+
+	handleError(lockB.Acquire(), t)
+	handleError(lockB.Release(), t)
+	if err := lockB.Release(); err != s3lib.ErrLockLost {
+		t.Errorf("expected `%v` when releasing twice, got `%v`", s3lib.ErrLockLost, err)
+	}
+}
+
+func TestLockTakeOverAfterExpiry(t *testing.T) {
+	client := newTestS3(t)
+	lockA := s3lib.NewLock(client, "test_lock_expiry", "a", 10*time.Millisecond)
+	lockB := s3lib.NewLock(client, "test_lock_expiry", "b", time.Minute)
+
+	handleError(lockA.Acquire(), t)
+	time.Sleep(20 * time.Millisecond)
+	handleError(lockB.Acquire(), t)
+
+	if err := lockA.Renew(); err != s3lib.ErrLockLost {
+		t.Errorf("expected `%v` when renewing a lock taken over, got `%v`", s3lib.ErrLockLost, err)
+	}
+	if err := lockA.Release(); err != s3lib.ErrLockLost {
+		t.Errorf("expected `%v` when releasing a lock taken over, got `%v`", s3lib.ErrLockLost, err)
+	}
+	handleError(lockB.Release(), t)
+}
+
+func TestLockReacquireBySameOwner(t *testing.T) {
+	client := newTestS3(t)
+	lock := s3lib.NewLock(client, "test_lock_owner", "a", time.Minute)
+	handleError(lock.Acquire(), t)
+
+	sameOwner := s3lib.NewLock(client, "test_lock_owner", "a", time.Minute)
+	handleError(sameOwner.Acquire(), t)
+	if err := lock.Renew(); err != s3lib.ErrLockLost {
+		t.Errorf("expected `%v` for the replaced lease, got `%v`", s3lib.ErrLockLost, err)
+	}
+	handleError(sameOwner.Release(), t)
+}
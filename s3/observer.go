@@ -0,0 +1,153 @@
+package s3
+
+import (
+	"time"
+)
+
+// Operation describes an operation performed through the `S3` wrapper,
+// as reported to observers.
+type Operation struct {
+	// Name is the name of the `S3` method (e.g. "FetchObject"), or
+	// "ListObjectsWithDelimiter" for the listings of `UsageReport`.
+	Name   string
+	Bucket string
+	// Key is the object key, the prefix for listing operations, or the
+	// delimiter for `FindLatestInTimestampPrefixedObjects`.
+	Key      string
+	Duration time.Duration
+	// Bytes is the number of content bytes uploaded or downloaded.
+	Bytes int64
+	Err   error
+}
+
+// Observer is notified of every operation performed through an `S3`
+// wrapper it has been added to (see `WithObserver`).
+//
+// Operations can be performed concurrently, e.g. by `UsageReport`, so
+// implementations must be safe for concurrent use.
+type Observer interface {
+	ObserveOperation(op Operation)
+}
+
+// ObserverFunc is an adapter to use a function as an `Observer`.
+type ObserverFunc func(op Operation)
+
+// ObserveOperation calls `f(op)`.
+func (f ObserverFunc) ObserveOperation(op Operation) {
+	f(op)
+}
+
+// WithObserver returns a copy of the S3 struct which notifies `o`
+// of every operation, in addition to its existing observers.
+func (s3 S3) WithObserver(o Observer) S3 {
+	observers := make([]Observer, 0, len(s3.observers)+1)
+	observers = append(observers, s3.observers...)
+	s3.observers = append(observers, o)
+	return s3
+}
+
+func (s3 S3) observe(name string, key string, start time.Time, bytes int64, err error) {
+	if len(s3.observers) == 0 {
+		return
+	}
+	op := Operation{
+		Name:     name,
+		Bucket:   s3.Bucket,
+		Key:      key,
+		Duration: time.Since(start),
+		Bytes:    bytes,
+		Err:      err,
+	}
+	for _, o := range s3.observers {
+		o.ObserveOperation(op)
+	}
+}
+
+// StructuredLogger is the subset of a structured logger's methods used
+// by `NewLogObserver`. It is satisfied by `*slog.Logger`.
+type StructuredLogger interface {
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// NewLogObserver returns an observer logging every operation with
+// `logger`, at the error level for failed operations.
+func NewLogObserver(logger StructuredLogger) Observer {
+	return ObserverFunc(func(op Operation) {
+		args := []interface{}{
+			"operation", op.Name,
+			"bucket", op.Bucket,
+			"key", op.Key,
+			"duration", op.Duration,
+			"bytes", op.Bytes,
+		}
+		if op.Err != nil {
+			logger.Error("s3 operation failed", append(args, "error", op.Err)...)
+			return
+		}
+		logger.Info("s3 operation", args...)
+	})
+}
+
+// Counter is a Prometheus-style counter with labels.
+type Counter interface {
+	Add(value float64, labels ...string)
+}
+
+// Histogram is a Prometheus-style histogram with labels.
+type Histogram interface {
+	Observe(value float64, labels ...string)
+}
+
+// CounterFunc is an adapter to use a function as a `Counter`, e.g.
+// to wrap a Prometheus `CounterVec`:
+//
+// ```
+// s3.CounterFunc(func(v float64, labels ...string) { vec.WithLabelValues(labels...).Add(v) })
+// ```
+//
+type CounterFunc func(value float64, labels ...string)
+
+// Add calls `f(value, labels...)`.
+func (f CounterFunc) Add(value float64, labels ...string) {
+	f(value, labels...)
+}
+
+// HistogramFunc is an adapter to use a function as a `Histogram`.
+type HistogramFunc func(value float64, labels ...string)
+
+// Observe calls `f(value, labels...)`.
+func (f HistogramFunc) Observe(value float64, labels ...string) {
+	f(value, labels...)
+}
+
+// MetricsObserver is an observer recording operations in counters and
+// histograms, all labelled with the operation name and the bucket (in
+// this order). Nil metrics are ignored.
+type MetricsObserver struct {
+	// Requests counts the operations.
+	Requests Counter
+	// Errors counts the failed operations.
+	Errors Counter
+	// Bytes counts the uploaded and downloaded bytes.
+	Bytes Counter
+	// Duration observes the operations' durations in seconds.
+	Duration Histogram
+}
+
+// ObserveOperation records `op` in the observer's metrics.
+func (m MetricsObserver) ObserveOperation(op Operation) {
+	labels := []string{op.Name, op.Bucket}
+	if m.Requests != nil {
+		m.Requests.Add(1, labels...)
+	}
+	if m.Errors != nil && op.Err != nil {
+		m.Errors.Add(1, labels...)
+	}
+	if m.Bytes != nil && op.Bytes > 0 {
+		m.Bytes.Add(float64(op.Bytes), labels...)
+	}
+	if m.Duration != nil {
+		m.Duration.Observe(op.Duration.Seconds(), labels...)
+	}
+}
@@ -0,0 +1,137 @@
+package s3_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	s3lib "golib/s3"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Info(msg string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint("INFO ", msg, args))
+}
+
+func (l *testLogger) Error(msg string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint("ERROR ", msg, args))
+}
+
+func TestWithObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := s3lib.NewS3FromProfile(s3lib.Profile{
+		Bucket:            "bucket",
+		Endpoint:          server.URL,
+		Region:            "us-east-1",
+		CredentialsSource: s3lib.CredentialsStatic,
+		AccessKeyID:       "key",
+		SecretAccessKey:   "secret",
+		ForcePathStyle:    true,
+	})
+	handleError(err, t)
+
+	ops := make([]s3lib.Operation, 0)
+	observed := client.WithObserver(s3lib.ObserverFunc(func(op s3lib.Operation) {
+		ops = append(ops, op)
+	}))
+
+	exists, err := observed.ObjectExists("missing")
+	handleError(err, t)
+	if exists {
+		t.Errorf("expected object not to exist")
+	}
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 observed operation, got %d", len(ops))
+	}
+	if ops[0].Name != "ObjectExists" || ops[0].Bucket != "bucket" || ops[0].Key != "missing" || ops[0].Err != nil {
+		t.Errorf("unexpected observed operation %+v", ops[0])
+	}
+
+	_, err = client.ObjectExists("missing")
+	handleError(err, t)
+	if len(ops) != 1 {
+		t.Errorf("expected the original client not to be observed")
+	}
+}
+
+func TestLogObserver(t *testing.T) {
+	logger := &testLogger{}
+	observer := s3lib.NewLogObserver(logger)
+	observer.ObserveOperation(s3lib.Operation{Name: "FetchObject", Bucket: "b", Key: "k", Duration: time.Second, Bytes: 3})
+	observer.ObserveOperation(s3lib.Operation{Name: "DeleteObject", Bucket: "b", Key: "k", Err: errors.New("boom")})
+
+	expected := []string{
+		"INFO s3 operation[operation FetchObject bucket b key k duration 1s bytes 3]",
+		"ERROR s3 operation failed[operation DeleteObject bucket b key k duration 0s bytes 0 error boom]",
+	}
+	if len(logger.lines) != len(expected) {
+		t.Fatalf("expected %d log lines, got %d (%v)", len(expected), len(logger.lines), logger.lines)
+	}
+	for i := range expected {
+		if logger.lines[i] != expected[i] {
+			t.Errorf("expected log line `%s`, got `%s`", expected[i], logger.lines[i])
+		}
+	}
+}
+
+func TestMetricsObserver(t *testing.T) {
+	values := make(map[string]float64)
+	metric := func(name string) func(float64, ...string) {
+		return func(v float64, labels ...string) {
+			values[fmt.Sprintf("%s%v", name, labels)] += v
+		}
+	}
+	observer := s3lib.MetricsObserver{
+		Requests: s3lib.CounterFunc(metric("requests")),
+		Errors:   s3lib.CounterFunc(metric("errors")),
+		Bytes:    s3lib.CounterFunc(metric("bytes")),
+		Duration: s3lib.HistogramFunc(metric("duration")),
+	}
+	observer.ObserveOperation(s3lib.Operation{Name: "CreateObject", Bucket: "b", Bytes: 10, Duration: time.Second})
+	observer.ObserveOperation(s3lib.Operation{Name: "CreateObject", Bucket: "b", Bytes: 5, Err: errors.New("boom")})
+
+	expected := map[string]float64{
+		"requests[CreateObject b]": 2,
+		"errors[CreateObject b]":   1,
+		"bytes[CreateObject b]":    15,
+		"duration[CreateObject b]": 1,
+	}
+	for k, v := range expected {
+		if values[k] != v {
+			t.Errorf("expected `%s` to be %v, got %v", k, v, values[k])
+		}
+	}
+}
+
+func TestObserveFindLatest(t *testing.T) {
+	client, _ := newFakeS3(t)
+	for _, key := range []string{"2016/1/1", "2017/1/1", "2017/2/2"} {
+		handleError(client.CreateObject(key, []byte("x")), t)
+	}
+
+	ops := make([]s3lib.Operation, 0)
+	observed := client.WithObserver(s3lib.ObserverFunc(func(op s3lib.Operation) {
+		ops = append(ops, op)
+	}))
+	foundKey, err := observed.FindLatestInTimestampPrefixedObjects("/")
+	handleError(err, t)
+	if foundKey == nil || *foundKey != "2017/2/2" {
+		t.Errorf("unexpected found key %v", foundKey)
+	}
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 observed operation, got %+v", ops)
+	}
+	if ops[0].Name != "FindLatestInTimestampPrefixedObjects" || ops[0].Key != "/" {
+		t.Errorf("unexpected observed operation %+v", ops[0])
+	}
+}
@@ -0,0 +1,207 @@
+package s3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// CredentialsSource defines where a `Profile` gets its credentials from.
+type CredentialsSource string
+
+const (
+	// CredentialsDefault uses the SDK's default credentials chain
+	// (environment, shared credentials file, instance role...).
+	CredentialsDefault CredentialsSource = ""
+	// CredentialsStatic uses the profile's `AccessKeyID`, `SecretAccessKey`
+	// and `SessionToken`.
+	CredentialsStatic CredentialsSource = "static"
+	// CredentialsShared uses the profile named `SharedProfile` in the
+	// shared credentials file (`~/.aws/credentials`).
+	CredentialsShared CredentialsSource = "shared"
+)
+
+// Profile is a named configuration for an S3-compatible provider
+// (AWS, MinIO, Scaleway...).
+type Profile struct {
+	Name              string            `json:"name"`
+	Bucket            string            `json:"bucket"`
+	Endpoint          string            `json:"endpoint"`
+	Region            string            `json:"region"`
+	CredentialsSource CredentialsSource `json:"credentials_source"`
+	AccessKeyID       string            `json:"access_key_id"`
+	SecretAccessKey   string            `json:"secret_access_key"`
+	SessionToken      string            `json:"session_token"`
+	SharedProfile     string            `json:"shared_profile"`
+
+	// ForcePathStyle uses `endpoint/bucket/key` URLs instead of
+	// `bucket.endpoint/key`, which most self-hosted providers require.
+	ForcePathStyle bool `json:"force_path_style"`
+	DisableSSL     bool `json:"disable_ssl"`
+
+	// InsecureSkipVerify disables TLS certificate verification. Only
+	// use it for testing.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// CACertFile is the path to a PEM file with additional certificate
+	// authorities to trust (e.g. for an on-prem MinIO).
+	CACertFile string `json:"ca_cert_file"`
+}
+
+// LoadProfiles loads the profiles defined in the JSON file at `filepath`.
+// The file must contain an object mapping profile names to profiles, e.g.:
+//
+// ```
+// {
+//   "minio": {"bucket": "data", "endpoint": "https://minio.local:9000", "force_path_style": true},
+//   "aws": {"bucket": "data", "region": "eu-west-3"}
+// }
+// ```
+//
+func LoadProfiles(filepath string) (map[string]Profile, error) {
+	profiles := make(map[string]Profile)
+	content, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return profiles, fmt.Errorf("failed to read profiles file: %v", err)
+	}
+	if err := json.Unmarshal(content, &profiles); err != nil {
+		return profiles, fmt.Errorf("failed to decode profiles file: %v", err)
+	}
+	for name, profile := range profiles {
+		profile.Name = name
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// LoadProfile loads the profile named `name` from the JSON file at
+// `filepath` (see `LoadProfiles`).
+func LoadProfile(filepath string, name string) (Profile, error) {
+	profiles, err := LoadProfiles(filepath)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile `%s` not found in `%s`", name, filepath)
+	}
+	return profile, nil
+}
+
+// ProfileFromEnv builds a profile from the environment variables with
+// the specified `prefix`. With the `AWS_` prefix, the variables from
+// `.env.example` are used:
+//
+//   - `<prefix>BUCKET`
+//   - `<prefix>ENDPOINT`
+//   - `<prefix>REGION`
+//   - `<prefix>CREDENTIALS_SOURCE` (`static` or `shared`, defaults to
+//     `static` if an access key is set)
+//   - `<prefix>ACCESS_KEY`, `<prefix>SECRET_KEY` and `<prefix>SESSION_TOKEN`
+//   - `<prefix>SHARED_PROFILE`
+//   - `<prefix>FORCE_PATH_STYLE`, `<prefix>DISABLE_SSL` and
+//     `<prefix>INSECURE_SKIP_VERIFY` (booleans)
+//   - `<prefix>CA_CERT_FILE`
+func ProfileFromEnv(prefix string) (Profile, error) {
+	profile := Profile{
+		Name:              prefix,
+		Bucket:            os.Getenv(prefix + "BUCKET"),
+		Endpoint:          os.Getenv(prefix + "ENDPOINT"),
+		Region:            os.Getenv(prefix + "REGION"),
+		CredentialsSource: CredentialsSource(os.Getenv(prefix + "CREDENTIALS_SOURCE")),
+		AccessKeyID:       os.Getenv(prefix + "ACCESS_KEY"),
+		SecretAccessKey:   os.Getenv(prefix + "SECRET_KEY"),
+		SessionToken:      os.Getenv(prefix + "SESSION_TOKEN"),
+		SharedProfile:     os.Getenv(prefix + "SHARED_PROFILE"),
+		CACertFile:        os.Getenv(prefix + "CA_CERT_FILE"),
+	}
+	if profile.CredentialsSource == CredentialsDefault && len(profile.AccessKeyID) > 0 {
+		profile.CredentialsSource = CredentialsStatic
+	}
+
+	flags := map[string]*bool{
+		"FORCE_PATH_STYLE":     &profile.ForcePathStyle,
+		"DISABLE_SSL":          &profile.DisableSSL,
+		"INSECURE_SKIP_VERIFY": &profile.InsecureSkipVerify,
+	}
+	for name, flag := range flags {
+		value := os.Getenv(prefix + name)
+		if len(value) == 0 {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return profile, fmt.Errorf("invalid boolean for `%s%s`: %v", prefix, name, err)
+		}
+		*flag = b
+	}
+	return profile, nil
+}
+
+// Config returns the AWS configuration matching the profile.
+func (p Profile) Config() (*aws.Config, error) {
+	config := aws.NewConfig()
+	if len(p.Endpoint) > 0 {
+		config.WithEndpoint(p.Endpoint)
+	}
+	if len(p.Region) > 0 {
+		config.WithRegion(p.Region)
+	}
+	if p.ForcePathStyle {
+		config.WithS3ForcePathStyle(true)
+	}
+	if p.DisableSSL {
+		config.WithDisableSSL(true)
+	}
+
+	switch p.CredentialsSource {
+	case CredentialsDefault:
+	case CredentialsStatic:
+		config.WithCredentials(credentials.NewStaticCredentials(p.AccessKeyID, p.SecretAccessKey, p.SessionToken))
+	case CredentialsShared:
+		config.WithCredentials(credentials.NewSharedCredentials("", p.SharedProfile))
+	default:
+		return nil, fmt.Errorf("unknown credentials source `%s`", p.CredentialsSource)
+	}
+
+	if p.InsecureSkipVerify || len(p.CACertFile) > 0 {
+		tlsConfig := &tls.Config{InsecureSkipVerify: p.InsecureSkipVerify}
+		if len(p.CACertFile) > 0 {
+			pem, err := ioutil.ReadFile(p.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA certificates: %v", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no valid certificate found in `%s`", p.CACertFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		config.WithHTTPClient(&http.Client{Transport: transport})
+	}
+	return config, nil
+}
+
+// NewS3FromProfile returns a valid S3 struct configured with the
+// specified profile, e.g. to target an S3-compatible provider.
+func NewS3FromProfile(p Profile) (S3, error) {
+	config, err := p.Config()
+	if err != nil {
+		return S3{}, err
+	}
+	s3 := NewS3(p.Bucket)
+	s3.config = config
+	return s3, nil
+}
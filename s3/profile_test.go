@@ -0,0 +1,86 @@
+package s3_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	s3lib "golib/s3"
+)
+
+func TestLoadProfile(t *testing.T) {
+	content := `{
+		"minio": {"bucket": "data", "endpoint": "http://localhost:9000", "region": "us-east-1",
+			"credentials_source": "static", "access_key_id": "key", "secret_access_key": "secret",
+			"force_path_style": true, "disable_ssl": true},
+		"aws": {"bucket": "prod", "region": "eu-west-3"}
+	}`
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	handleError(ioutil.WriteFile(path, []byte(content), 0600), t)
+
+	profile, err := s3lib.LoadProfile(path, "minio")
+	handleError(err, t)
+	if profile.Name != "minio" || profile.Bucket != "data" || !profile.ForcePathStyle {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	config, err := profile.Config()
+	handleError(err, t)
+	if *config.Endpoint != "http://localhost:9000" || !*config.S3ForcePathStyle || !*config.DisableSSL {
+		t.Errorf("unexpected config for profile %+v", profile)
+	}
+	creds, err := config.Credentials.Get()
+	handleError(err, t)
+	if creds.AccessKeyID != "key" || creds.SecretAccessKey != "secret" {
+		t.Errorf("expected static credentials from profile, got %+v", creds)
+	}
+
+	client, err := s3lib.NewS3FromProfile(profile)
+	handleError(err, t)
+	if client.Bucket != "data" {
+		t.Errorf("expected bucket `data`, got `%s`", client.Bucket)
+	}
+
+	_, err = s3lib.LoadProfile(path, "nope")
+	if err == nil {
+		t.Errorf("expected an error for an unknown profile")
+	}
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	env := map[string]string{
+		"TEST_S3_BUCKET":           "data",
+		"TEST_S3_ENDPOINT":         "https://s3.fr-par.scw.cloud",
+		"TEST_S3_REGION":           "fr-par",
+		"TEST_S3_ACCESS_KEY":       "key",
+		"TEST_S3_SECRET_KEY":       "secret",
+		"TEST_S3_FORCE_PATH_STYLE": "true",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	profile, err := s3lib.ProfileFromEnv("TEST_S3_")
+	handleError(err, t)
+	expected := s3lib.Profile{
+		Name:              "TEST_S3_",
+		Bucket:            "data",
+		Endpoint:          "https://s3.fr-par.scw.cloud",
+		Region:            "fr-par",
+		CredentialsSource: s3lib.CredentialsStatic,
+		AccessKeyID:       "key",
+		SecretAccessKey:   "secret",
+		ForcePathStyle:    true,
+	}
+	if profile != expected {
+		t.Errorf("expected profile %+v, got %+v", expected, profile)
+	}
+
+	os.Setenv("TEST_S3_DISABLE_SSL", "maybe")
+	defer os.Unsetenv("TEST_S3_DISABLE_SSL")
+	if _, err := s3lib.ProfileFromEnv("TEST_S3_"); err == nil {
+		t.Errorf("expected an error for an invalid boolean")
+	}
+}
@@ -0,0 +1,281 @@
+package s3
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsS3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// DefaultAgeBuckets are the upper bounds of the age histogram buckets
+// used when none are specified in `UsageReportOptions`. Objects older
+// than the last bound are counted in an additional bucket.
+var DefaultAgeBuckets = []time.Duration{
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+	90 * 24 * time.Hour,
+	365 * 24 * time.Hour,
+}
+
+// UsageReportOptions configures `UsageReport`.
+type UsageReportOptions struct {
+	// Delimiter splits keys into the prefix tree (defaults to "/").
+	Delimiter string
+	// Concurrency is the maximum number of concurrent listings, and of
+	// goroutines listing (defaults to 8).
+	Concurrency int
+	// AgeBuckets are the upper bounds of the age histogram buckets
+	// (defaults to `DefaultAgeBuckets`).
+	AgeBuckets []time.Duration
+	// Now is the reference time to compute ages (defaults to the
+	// current time).
+	Now time.Time
+}
+
+// Usage is an object count and a total size.
+type Usage struct {
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
+
+func (u *Usage) add(other Usage) {
+	u.Objects += other.Objects
+	u.Bytes += other.Bytes
+}
+
+// PrefixUsage is the usage summary of the objects under a prefix,
+// including its sub-prefixes.
+type PrefixUsage struct {
+	Prefix string `json:"prefix"`
+	Usage
+	StorageClasses map[string]Usage `json:"storage_classes"`
+	// Ages is the age histogram, with one bucket per bound in `AgeBuckets`
+	// plus one for the older objects.
+	Ages       []Usage         `json:"ages"`
+	AgeBuckets []time.Duration `json:"age_buckets"`
+	Children   []*PrefixUsage  `json:"children,omitempty"`
+}
+
+func newPrefixUsage(prefix string, ageBuckets []time.Duration) *PrefixUsage {
+	return &PrefixUsage{
+		Prefix:         prefix,
+		StorageClasses: make(map[string]Usage),
+		Ages:           make([]Usage, len(ageBuckets)+1),
+		AgeBuckets:     ageBuckets,
+	}
+}
+
+func (p *PrefixUsage) addChild(child *PrefixUsage) {
+	p.Usage.add(child.Usage)
+	for class, usage := range child.StorageClasses {
+		classUsage := p.StorageClasses[class]
+		classUsage.add(usage)
+		p.StorageClasses[class] = classUsage
+	}
+	for i := range child.Ages {
+		p.Ages[i].add(child.Ages[i])
+	}
+	p.Children = append(p.Children, child)
+}
+
+// UsageReport walks the prefix tree under `prefix`, level by level,
+// listing the prefixes of each level concurrently, and returns the usage
+// summary of every prefix: object counts, total bytes, storage class
+// breakdown and age histogram.
+func (s3 S3) UsageReport(prefix string, opts UsageReportOptions) (*PrefixUsage, error) {
+	if len(opts.Delimiter) == 0 {
+		opts.Delimiter = "/"
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 8
+	}
+	if opts.AgeBuckets == nil {
+		opts.AgeBuckets = DefaultAgeBuckets
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	// A single client is shared by the listings to reuse connections
+	awsS3Client := awsS3.New(s3.newSession())
+
+	root := newPrefixUsage(prefix, opts.AgeBuckets)
+	levels := make([][]*PrefixUsage, 0)
+	parents := make([][]*PrefixUsage, 0)
+	level, levelParents := []*PrefixUsage{root}, []*PrefixUsage{nil}
+	for len(level) > 0 {
+		levels = append(levels, level)
+		parents = append(parents, levelParents)
+
+		commonPrefixes := make([][]string, len(level))
+		err := forEachConcurrently(len(level), opts.Concurrency, func(i int) error {
+			report := level[i]
+			objects, children, err := s3.listWithDelimiter(awsS3Client, report.Prefix, opts.Delimiter)
+			if err != nil {
+				return err
+			}
+			for _, object := range objects {
+				usage := Usage{Objects: 1, Bytes: object.Size}
+				report.Usage.add(usage)
+				classUsage := report.StorageClasses[object.StorageClass]
+				classUsage.add(usage)
+				report.StorageClasses[object.StorageClass] = classUsage
+				age := opts.Now.Sub(object.LastModified)
+				bucket := sort.Search(len(opts.AgeBuckets), func(i int) bool { return age < opts.AgeBuckets[i] })
+				report.Ages[bucket].add(usage)
+			}
+			commonPrefixes[i] = children
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		next, nextParents := make([]*PrefixUsage, 0), make([]*PrefixUsage, 0)
+		for i, report := range level {
+			for _, commonPrefix := range commonPrefixes[i] {
+				next = append(next, newPrefixUsage(commonPrefix, opts.AgeBuckets))
+				nextParents = append(nextParents, report)
+			}
+		}
+		level, levelParents = next, nextParents
+	}
+
+	// Children are complete once the deeper levels have been added to them
+	for depth := len(levels) - 1; depth > 0; depth-- {
+		for i, report := range levels[depth] {
+			parents[depth][i].addChild(report)
+		}
+	}
+	return root, nil
+}
+
+// forEachConcurrently calls `f` with the indexes from 0 to `n`, from at
+// most `concurrency` goroutines, and returns the first error by index.
+func forEachConcurrently(n int, concurrency int, f func(i int) error) error {
+	indexes := make(chan int)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listWithDelimiter lists the objects directly under `prefix` and the
+// common prefixes grouping the other ones.
+func (s3 S3) listWithDelimiter(awsS3Client *awsS3.S3, prefix string, delimiter string) (objects []ObjectInfo, commonPrefixes []string, err error) {
+	start := time.Now()
+	defer func() { s3.observe("ListObjectsWithDelimiter", prefix, start, 0, err) }()
+
+	params := &awsS3.ListObjectsInput{
+		Bucket:    aws.String(s3.Bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String(delimiter),
+	}
+	err = awsS3Client.ListObjectsPages(params,
+		func(page *awsS3.ListObjectsOutput, lastPage bool) bool {
+			for _, item := range page.Contents {
+				objects = append(objects, newObjectInfo(item))
+			}
+			for _, item := range page.CommonPrefixes {
+				commonPrefixes = append(commonPrefixes, aws.StringValue(item.Prefix))
+			}
+			return !lastPage
+		},
+	)
+	sort.Strings(commonPrefixes)
+	return objects, commonPrefixes, err
+}
+
+// WriteCSV writes the report as CSV, with one row per prefix (depth
+// first). The columns are the prefix, its depth, the object count and
+// total bytes, then the count and bytes per storage class and per age
+// bucket.
+func (p *PrefixUsage) WriteCSV(w io.Writer) error {
+	classes := make([]string, 0)
+	for class := range p.StorageClasses {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	header := []string{"prefix", "depth", "objects", "bytes"}
+	for _, class := range classes {
+		header = append(header, class+"_objects", class+"_bytes")
+	}
+	for i := range p.Ages {
+		label := "age_all"
+		if i < len(p.AgeBuckets) {
+			label = "age_lt_" + formatAge(p.AgeBuckets[i])
+		} else if i > 0 {
+			label = "age_gte_" + formatAge(p.AgeBuckets[i-1])
+		}
+		header = append(header, label+"_objects", label+"_bytes")
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	var writeRows func(report *PrefixUsage, depth int) error
+	writeRows = func(report *PrefixUsage, depth int) error {
+		row := []string{report.Prefix, strconv.Itoa(depth), formatInt(report.Objects), formatInt(report.Bytes)}
+		for _, class := range classes {
+			usage := report.StorageClasses[class]
+			row = append(row, formatInt(usage.Objects), formatInt(usage.Bytes))
+		}
+		for _, usage := range report.Ages {
+			row = append(row, formatInt(usage.Objects), formatInt(usage.Bytes))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		for _, child := range report.Children {
+			if err := writeRows(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writeRows(p, 0); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+// formatAge formats durations in days when possible (e.g. "7d").
+func formatAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
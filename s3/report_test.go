@@ -0,0 +1,75 @@
+package s3_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	s3lib "golib/s3"
+)
+
+func TestUsageReport(t *testing.T) {
+	client, fake := newFakeS3(t)
+	objects := map[string]string{
+		"root":       "1",
+		"a/x":        "22",
+		"a/y":        "333",
+		"a/deep/z":   "4444",
+		"b/archived": "55555",
+	}
+	for key, content := range objects {
+		handleError(client.CreateObject(key, []byte(content)), t)
+	}
+	fake.objects["b/archived"].storageClass = "GLACIER"
+	fake.objects["b/archived"].lastModified = time.Now().Add(-400 * 24 * time.Hour)
+
+	var mu sync.Mutex
+	listings := make(map[string]int)
+	observed := client.WithObserver(s3lib.ObserverFunc(func(op s3lib.Operation) {
+		mu.Lock()
+		defer mu.Unlock()
+		listings[op.Name]++
+	}))
+	report, err := observed.UsageReport("", s3lib.UsageReportOptions{Concurrency: 2})
+	handleError(err, t)
+	if len(listings) != 1 || listings["ListObjectsWithDelimiter"] != 4 {
+		t.Errorf("expected 4 listings to be observed, got %v", listings)
+	}
+
+	if report.Objects != 5 || report.Bytes != 15 {
+		t.Errorf("expected 5 objects and 15 bytes, got %d and %d", report.Objects, report.Bytes)
+	}
+	if report.StorageClasses["GLACIER"] != (s3lib.Usage{Objects: 1, Bytes: 5}) {
+		t.Errorf("unexpected GLACIER usage %+v", report.StorageClasses["GLACIER"])
+	}
+	if report.Ages[0].Objects != 4 || report.Ages[len(report.Ages)-1].Objects != 1 {
+		t.Errorf("unexpected age histogram %+v", report.Ages)
+	}
+	if len(report.Children) != 2 || report.Children[0].Prefix != "a/" || report.Children[0].Objects != 3 {
+		t.Fatalf("unexpected children %+v", report.Children)
+	}
+	if len(report.Children[0].Children) != 1 || report.Children[0].Children[0].Prefix != "a/deep/" {
+		t.Errorf("unexpected grand-children %+v", report.Children[0].Children)
+	}
+
+	var out bytes.Buffer
+	handleError(report.WriteCSV(&out), t)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expectedHeader := "prefix,depth,objects,bytes,GLACIER_objects,GLACIER_bytes,STANDARD_objects,STANDARD_bytes," +
+		"age_lt_1d_objects,age_lt_1d_bytes,age_lt_7d_objects,age_lt_7d_bytes,age_lt_30d_objects,age_lt_30d_bytes," +
+		"age_lt_90d_objects,age_lt_90d_bytes,age_lt_365d_objects,age_lt_365d_bytes,age_gte_365d_objects,age_gte_365d_bytes"
+	if lines[0] != expectedHeader {
+		t.Errorf("expected CSV header `%s`, got `%s`", expectedHeader, lines[0])
+	}
+	expectedRows := []string{
+		",0,5,15,1,5,4,10,4,10,0,0,0,0,0,0,0,0,1,5",
+		"a/,1,3,9,0,0,3,9,3,9,0,0,0,0,0,0,0,0,0,0",
+		"a/deep/,2,1,4,0,0,1,4,1,4,0,0,0,0,0,0,0,0,0,0",
+		"b/,1,1,5,1,5,0,0,0,0,0,0,0,0,0,0,0,0,1,5",
+	}
+	if strings.Join(lines[1:], "\n") != strings.Join(expectedRows, "\n") {
+		t.Errorf("expected CSV rows:\n%s\ngot:\n%s", strings.Join(expectedRows, "\n"), strings.Join(lines[1:], "\n"))
+	}
+}
@@ -4,10 +4,19 @@ package s3
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsS3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -15,7 +24,9 @@ import (
 
 // S3 is a wrapper around AWS S3 SDK.
 type S3 struct {
-	Bucket string
+	Bucket    string
+	config    *aws.Config
+	observers []Observer
 }
 
 // NewS3 returns a valid S3 struct. Please use it to
@@ -26,13 +37,29 @@ func NewS3(bucket string) S3 {
 	}
 }
 
+// newSession returns a new AWS session using the client's configuration
+// (see `NewS3FromProfile`). Without configuration, the session relies on
+// the environment, as described in `.env.example`.
+func (s3 S3) newSession() *session.Session {
+	return session.Must(session.NewSession(s3.config))
+}
+
 // ListObjects list objects stored in the client's S3 bucket with
 // the specified `prefix` and returns their keys.
-func (s3 S3) ListObjects(prefix string) ([]string, error) {
-	objectKeys := make([]string, 0)
-	sess := session.Must(session.NewSession())
+func (s3 S3) ListObjects(prefix string) (objectKeys []string, err error) {
+	start := time.Now()
+	defer func() { s3.observe("ListObjects", prefix, start, 0, err) }()
+
+	sess := s3.newSession()
 	awsS3Client := awsS3.New(sess)
+	return s3.listKeys(awsS3Client, prefix)
+}
 
+// listKeys lists the keys with the specified `prefix`, without observing
+// the operation, for the methods listing objects as part of another
+// operation.
+func (s3 S3) listKeys(awsS3Client *awsS3.S3, prefix string) ([]string, error) {
+	objectKeys := make([]string, 0)
 	params := &awsS3.ListObjectsInput{
 		Bucket: aws.String(s3.Bucket),
 		Prefix: aws.String(prefix),
@@ -60,6 +87,51 @@ func (s3 S3) ListObjects(prefix string) ([]string, error) {
 	return objectKeys, nil
 }
 
+// ObjectInfo describes an object stored on S3, as returned by
+// `ListObjectsInfo`.
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	StorageClass string    `json:"storage_class"`
+	ETag         string    `json:"etag"`
+}
+
+// ListObjectsInfo list objects stored in the client's S3 bucket with
+// the specified `prefix` and returns their description.
+func (s3 S3) ListObjectsInfo(prefix string) (objects []ObjectInfo, err error) {
+	start := time.Now()
+	defer func() { s3.observe("ListObjectsInfo", prefix, start, 0, err) }()
+
+	objects = make([]ObjectInfo, 0)
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	params := &awsS3.ListObjectsInput{
+		Bucket: aws.String(s3.Bucket),
+		Prefix: aws.String(prefix),
+	}
+	err = awsS3Client.ListObjectsPages(params,
+		func(page *awsS3.ListObjectsOutput, lastPage bool) bool {
+			for _, item := range page.Contents {
+				objects = append(objects, newObjectInfo(item))
+			}
+			return !lastPage
+		},
+	)
+	return objects, err
+}
+
+func newObjectInfo(item *awsS3.Object) ObjectInfo {
+	return ObjectInfo{
+		Key:          aws.StringValue(item.Key),
+		Size:         aws.Int64Value(item.Size),
+		LastModified: aws.TimeValue(item.LastModified),
+		StorageClass: aws.StringValue(item.StorageClass),
+		ETag:         aws.StringValue(item.ETag),
+	}
+}
+
 // FindLatestInTimestampPrefixedObjects will return the key of the latest
 // object by searching the greatest date. For this method to work, objects
 // must be prefixed with a timestamp (e.g. ISO-8601-formatted date strings).
@@ -81,8 +153,11 @@ func (s3 S3) ListObjects(prefix string) ([]string, error) {
 //     Using delimiter will help supporting a larger total number of objects, as
 //     each delimited group may contain up to 1000 objects.
 //
-func (s3 S3) FindLatestInTimestampPrefixedObjects(delimiter string) (*string, error) {
-	sess := session.Must(session.NewSession())
+func (s3 S3) FindLatestInTimestampPrefixedObjects(delimiter string) (latestKey *string, err error) {
+	start := time.Now()
+	defer func() { s3.observe("FindLatestInTimestampPrefixedObjects", delimiter, start, 0, err) }()
+
+	sess := s3.newSession()
 	awsS3Client := awsS3.New(sess)
 
 	params := &awsS3.ListObjectsInput{
@@ -118,7 +193,7 @@ func (s3 S3) FindLatestInTimestampPrefixedObjects(delimiter string) (*string, er
 		return nil, err
 	}
 
-	objectKeys, err := s3.ListObjects(greatestPrefix)
+	objectKeys, err := s3.listKeys(awsS3Client, greatestPrefix)
 	if err != nil {
 		return nil, err
 	}
@@ -132,9 +207,16 @@ func (s3 S3) FindLatestInTimestampPrefixedObjects(delimiter string) (*string, er
 }
 
 // FetchObject fetches the content of the object specified by its key.
-func (s3 S3) FetchObject(key string) ([]byte, error) {
-	var content []byte
-	sess := session.Must(session.NewSession())
+//
+// If the object was stored with a checksum (see `CreateObject`), the
+// fetched content is verified against it and a `*ChecksumMismatchError`
+// is returned if they don't match. Objects stored without a checksum
+// are returned without verification.
+func (s3 S3) FetchObject(key string) (content []byte, err error) {
+	start := time.Now()
+	defer func() { s3.observe("FetchObject", key, start, int64(len(content)), err) }()
+
+	sess := s3.newSession()
 	input := &awsS3.GetObjectInput{
 		Bucket: aws.String(s3.Bucket),
 		Key:    aws.String(key),
@@ -143,42 +225,268 @@ func (s3 S3) FetchObject(key string) ([]byte, error) {
 
 	// Write the contents of S3 Object to a buffer
 	buff := &aws.WriteAtBuffer{}
-	_, err := downloader.Download(buff, input)
+	recorder := &metadataRecorder{}
+	_, err = downloader.Download(buff, input, s3manager.WithDownloaderRequestOptions(recorder.record))
 	if err != nil {
 		return content, fmt.Errorf("Failed to download object, %v", err)
 	}
-	return buff.Bytes(), nil
+	content = buff.Bytes()
+	if err := verifyChecksum(key, content, recorder.metadata); err != nil {
+		return content, err
+	}
+	return content, nil
+}
+
+// FetchObjectTo writes the content of the object specified by its key
+// to `w` as it is downloaded, without holding it in memory, and returns
+// the number of bytes written.
+//
+// The content is verified against the object's checksum like in
+// `FetchObject`, once it has been entirely written: a
+// `*ChecksumMismatchError` means that `w` received corrupted content.
+func (s3 S3) FetchObjectTo(key string, w io.Writer) (n int64, err error) {
+	start := time.Now()
+	defer func() { s3.observe("FetchObjectTo", key, start, n, err) }()
+
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	output, err := awsS3Client.GetObject(&awsS3.GetObjectInput{
+		Bucket: aws.String(s3.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download object, %v", err)
+	}
+	defer output.Body.Close()
+
+	hash := sha256.New()
+	n, err = io.Copy(io.MultiWriter(w, hash), output.Body)
+	if err != nil {
+		return n, fmt.Errorf("failed to download object, %v", err)
+	}
+	return n, verifySum(key, hex.EncodeToString(hash.Sum(nil)), output.Metadata)
+}
+
+// metadataRecorder records the object's metadata from the responses of
+// a download, whose ranged requests are sent concurrently.
+type metadataRecorder struct {
+	mu       sync.Mutex
+	metadata map[string]*string
+}
+
+func (m *metadataRecorder) record(r *request.Request) {
+	r.Handlers.Complete.PushBack(func(r *request.Request) {
+		output, ok := r.Data.(*awsS3.GetObjectOutput)
+		if !ok || r.Error != nil {
+			return
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.metadata == nil {
+			m.metadata = output.Metadata
+		}
+	})
 }
 
 // CreateObject creates a new object on S3 with the specified key and content.
-func (s3 S3) CreateObject(key string, content []byte) error {
-	sess := session.Must(session.NewSession())
+//
+// The content's SHA-256 is stored in the object's metadata so it can be
+// verified by `FetchObject`. When the content is uploaded in a single
+// part, its MD5 is also sent as `Content-MD5` so S3 rejects corrupted
+// uploads.
+func (s3 S3) CreateObject(key string, content []byte) (err error) {
+	start := time.Now()
+	defer func() { s3.observe("CreateObject", key, start, int64(len(content)), err) }()
+
+	sess := s3.newSession()
 	uploader := s3manager.NewUploader(sess)
 
-	r := bytes.NewReader(content)
-	_, err := uploader.Upload(&s3manager.UploadInput{
+	input := &s3manager.UploadInput{
 		Bucket: aws.String(s3.Bucket),
 		Key:    aws.String(key),
-		Body:   r,
-	})
+		Body:   bytes.NewReader(content),
+		Metadata: map[string]*string{
+			checksumMetadataKey: aws.String(checksumSHA256(content)),
+		},
+	}
+	// `Content-MD5` is dropped by multipart uploads
+	if int64(len(content)) < uploader.PartSize {
+		input.ContentMD5 = aws.String(contentMD5(content))
+	}
+	_, err = uploader.Upload(input)
 	if err != nil {
 		return fmt.Errorf("failed to upload object, %v", err)
 	}
 	return nil
 }
 
+// maxCopySize is the size of the largest object S3 copies in a single
+// request.
+const maxCopySize = 5 * 1024 * 1024 * 1024
+
+// CreateObjectFrom creates a new object on S3 with the specified key and
+// the content read from `r`, streamed through the uploader without
+// holding it in memory, and returns the number of bytes uploaded.
+//
+// The content's SHA-256 is stored in the object's metadata like with
+// `CreateObject`. If `r` can seek (e.g. a regular file), it is read a
+// first time to compute it, and uploaded with the object. Otherwise, it
+// is computed while uploading, and stored afterwards by copying the
+// object onto itself.
+//
+// ### NB: limitations
+//
+//   - `Content-MD5` is not sent, the content being unknown before the
+//     upload.
+//   - S3 copies objects of up to 5 GB in a single request: larger
+//     objects read from a reader which cannot seek are stored without
+//     checksum.
+//
+func (s3 S3) CreateObjectFrom(key string, r io.Reader) (n int64, err error) {
+	start := time.Now()
+	defer func() { s3.observe("CreateObjectFrom", key, start, n, err) }()
+
+	sess := s3.newSession()
+	uploader := s3manager.NewUploader(sess)
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s3.Bucket),
+		Key:    aws.String(key),
+	}
+	hash := sha256.New()
+
+	seeker, seekable := r.(io.Seeker)
+	var offset int64
+	if seekable {
+		// Seeking fails on pipes and terminals
+		offset, err = seeker.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+	}
+	if seekable {
+		if n, err = io.Copy(hash, r); err != nil {
+			return 0, fmt.Errorf("failed to read content, %v", err)
+		}
+		if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("failed to read content, %v", err)
+		}
+		input.Body = r
+		input.Metadata = map[string]*string{
+			checksumMetadataKey: aws.String(hex.EncodeToString(hash.Sum(nil))),
+		}
+		if _, err = uploader.Upload(input); err != nil {
+			return 0, fmt.Errorf("failed to upload object, %v", err)
+		}
+		return n, nil
+	}
+
+	counter := &byteCounter{}
+	input.Body = io.TeeReader(r, io.MultiWriter(hash, counter))
+	if _, err = uploader.Upload(input); err != nil {
+		return 0, fmt.Errorf("failed to upload object, %v", err)
+	}
+	n = counter.n
+	if n > maxCopySize {
+		return n, nil
+	}
+	err = s3.replaceMetadata(awsS3.New(sess), key, map[string]*string{
+		checksumMetadataKey: aws.String(hex.EncodeToString(hash.Sum(nil))),
+	})
+	if err != nil {
+		return n, fmt.Errorf("failed to store object checksum, %v", err)
+	}
+	return n, nil
+}
+
+// replaceMetadata copies the object onto itself with `metadata`, which
+// also updates its last modification date.
+func (s3 S3) replaceMetadata(awsS3Client *awsS3.S3, key string, metadata map[string]*string) error {
+	_, err := awsS3Client.CopyObject(&awsS3.CopyObjectInput{
+		Bucket:            aws.String(s3.Bucket),
+		Key:               aws.String(key),
+		CopySource:        aws.String(url.PathEscape(s3.Bucket + "/" + key)),
+		MetadataDirective: aws.String(awsS3.MetadataDirectiveReplace),
+		Metadata:          metadata,
+	})
+	return err
+}
+
+// byteCounter is an `io.Writer` counting the bytes written to it.
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
 // DeleteObject deletes the object with the specified key.
-func (s3 S3) DeleteObject(key string) error {
-	sess := session.Must(session.NewSession())
+func (s3 S3) DeleteObject(key string) (err error) {
+	start := time.Now()
+	defer func() { s3.observe("DeleteObject", key, start, 0, err) }()
+
+	sess := s3.newSession()
 	awsS3Client := awsS3.New(sess)
 
 	input := &awsS3.DeleteObjectInput{
 		Bucket: aws.String(s3.Bucket),
 		Key:    aws.String(key),
 	}
-	_, err := awsS3Client.DeleteObject(input)
+	_, err = awsS3Client.DeleteObject(input)
 	if err != nil {
 		return fmt.Errorf("failed to delete object, %v", err)
 	}
 	return nil
 }
+
+// TouchObject updates the last modification date of the object with
+// the specified key, keeping its content and metadata, and reports
+// whether it exists.
+//
+// The object is copied onto itself, which S3 does in a single request
+// for objects of up to 5 GB.
+func (s3 S3) TouchObject(key string) (exists bool, err error) {
+	start := time.Now()
+	defer func() { s3.observe("TouchObject", key, start, 0, err) }()
+
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	output, err := awsS3Client.HeadObject(&awsS3.HeadObjectInput{
+		Bucket: aws.String(s3.Bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		err = s3.replaceMetadata(awsS3Client, key, output.Metadata)
+	}
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to touch object, %v", err)
+	}
+	return true, nil
+}
+
+// ObjectExists checks if an object with the specified key exists
+// using a `HEAD` request, so the object's content is not downloaded.
+func (s3 S3) ObjectExists(key string) (exists bool, err error) {
+	start := time.Now()
+	defer func() { s3.observe("ObjectExists", key, start, 0, err) }()
+
+	sess := s3.newSession()
+	awsS3Client := awsS3.New(sess)
+
+	input := &awsS3.HeadObjectInput{
+		Bucket: aws.String(s3.Bucket),
+		Key:    aws.String(key),
+	}
+	_, err = awsS3Client.HeadObject(input)
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to head object, %v", err)
+	}
+	return true, nil
+}
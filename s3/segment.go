@@ -0,0 +1,254 @@
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golib"
+)
+
+// ErrInvalidRecord is returned when writing a record containing a
+// newline, which is used to separate records in segments.
+var ErrInvalidRecord = errors.New("record must not contain a newline")
+
+// SegmentWriter writes records to S3 as append-only log segments.
+//
+// Records are buffered and written as a segment (one record per line)
+// once the buffer reaches `MaxSize` bytes or its oldest record is older
+// than `MaxAge`. Segments are stored under `<prefix><timestamp>`, the
+// timestamp (see `golib.Timestamp`) being the UTC time of the segment's
+// first record, so segments are ordered by time.
+//
+// The age of the buffer is only checked when writing, so `Flush` should
+// be called periodically on low-traffic logs, and on shutdown.
+//
+// ### NB: limitations
+//
+//   - Several writers using the same prefix may generate the same
+//     segment key. Use a distinct prefix per writer.
+//
+type SegmentWriter struct {
+	S3      S3
+	Prefix  string
+	MaxSize int
+	MaxAge  time.Duration
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	first   time.Time
+	lastKey string
+	seq     int
+}
+
+// NewSegmentWriter returns a writer storing segments under `prefix`,
+// rolling them when they reach `maxSize` bytes or `maxAge`. A zero
+// `maxSize` or `maxAge` disables the corresponding limit.
+func NewSegmentWriter(s3 S3, prefix string, maxSize int, maxAge time.Duration) *SegmentWriter {
+	return &SegmentWriter{
+		S3:      s3,
+		Prefix:  prefix,
+		MaxSize: maxSize,
+		MaxAge:  maxAge,
+	}
+}
+
+// Write appends a record to the current segment, rolling it if it is
+// full or too old.
+func (w *SegmentWriter) Write(record []byte) error {
+	if bytes.IndexByte(record, '\n') >= 0 {
+		return ErrInvalidRecord
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if w.buf.Len() > 0 && w.MaxAge > 0 && now.Sub(w.first) >= w.MaxAge {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if w.buf.Len() == 0 {
+		w.first = now
+	}
+	w.buf.Write(record)
+	w.buf.WriteByte('\n')
+	if w.MaxSize > 0 && w.buf.Len() >= w.MaxSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered records as a new segment, if any.
+func (w *SegmentWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+// Close flushes the buffered records.
+func (w *SegmentWriter) Close() error {
+	return w.Flush()
+}
+
+func (w *SegmentWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	key := w.Prefix + golib.Timestamp(w.first.UTC())
+	if key <= w.lastKey {
+		// Several segments started in the same millisecond
+		w.seq++
+		key = fmt.Sprintf("%s-%04d", key, w.seq)
+	} else {
+		w.seq = 0
+	}
+	if err := w.S3.CreateObject(key, w.buf.Bytes()); err != nil {
+		return err
+	}
+	w.lastKey = key
+	w.buf.Reset()
+	return nil
+}
+
+// CompactSegments merges consecutive segments under `prefix` into
+// segments of up to `targetSize` bytes and returns the number of
+// segments removed. Segments already larger than `targetSize` are
+// left untouched.
+//
+// The merged content is written to the first segment of each merged
+// group, then the other segments of the group are deleted. If the
+// compaction is interrupted in between, some records will be duplicated.
+func (s3 S3) CompactSegments(prefix string, targetSize int64) (int, error) {
+	segments, err := s3.ListObjectsInfo(prefix)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Key < segments[j].Key })
+
+	removed := 0
+	group := make([]ObjectInfo, 0)
+	var groupSize int64
+	mergeGroup := func() error {
+		defer func() {
+			group = group[:0]
+			groupSize = 0
+		}()
+		if len(group) < 2 {
+			return nil
+		}
+		var merged bytes.Buffer
+		for _, segment := range group {
+			content, err := s3.FetchObject(segment.Key)
+			if err != nil {
+				return err
+			}
+			merged.Write(content)
+		}
+		if err := s3.CreateObject(group[0].Key, merged.Bytes()); err != nil {
+			return err
+		}
+		for _, segment := range group[1:] {
+			if err := s3.DeleteObject(segment.Key); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	}
+
+	for _, segment := range segments {
+		if groupSize+segment.Size > targetSize {
+			if err := mergeGroup(); err != nil {
+				return removed, err
+			}
+		}
+		group = append(group, segment)
+		groupSize += segment.Size
+	}
+	if err := mergeGroup(); err != nil {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// SegmentReader iterates over the records of the segments stored under
+// a prefix, in time order.
+//
+// Example:
+//
+// ```
+// r := NewSegmentReader(s3, "events/")
+// for r.Next() {
+//   process(r.Record())
+// }
+// if err := r.Err(); err != nil {
+//   ...
+// }
+// ```
+//
+type SegmentReader struct {
+	s3      S3
+	prefix  string
+	keys    []string
+	listed  bool
+	records [][]byte
+	record  []byte
+	err     error
+}
+
+// NewSegmentReader returns a reader over the segments under `prefix`.
+func NewSegmentReader(s3 S3, prefix string) *SegmentReader {
+	return &SegmentReader{
+		s3:     s3,
+		prefix: prefix,
+	}
+}
+
+// Next advances to the next record, returning false when there are
+// no more records or an error occurred (see `Err`).
+func (r *SegmentReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if !r.listed {
+		r.keys, r.err = r.s3.ListObjects(r.prefix)
+		if r.err != nil {
+			return false
+		}
+		sort.Strings(r.keys)
+		r.listed = true
+	}
+	for len(r.records) == 0 {
+		if len(r.keys) == 0 {
+			r.record = nil
+			return false
+		}
+		var content []byte
+		content, r.err = r.s3.FetchObject(r.keys[0])
+		if r.err != nil {
+			return false
+		}
+		r.keys = r.keys[1:]
+		r.records = bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n"))
+		if len(content) == 0 {
+			r.records = nil
+		}
+	}
+	r.record = r.records[0]
+	r.records = r.records[1:]
+	return true
+}
+
+// Record returns the current record.
+func (r *SegmentReader) Record() []byte {
+	return r.record
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (r *SegmentReader) Err() error {
+	return r.err
+}
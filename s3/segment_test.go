@@ -0,0 +1,86 @@
+package s3_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	s3lib "golib/s3"
+)
+
+func readSegmentRecords(t *testing.T, client s3lib.S3, prefix string) []string {
+	records := make([]string, 0)
+	r := s3lib.NewSegmentReader(client, prefix)
+	for r.Next() {
+		records = append(records, string(r.Record()))
+	}
+	handleError(r.Err(), t)
+	return records
+}
+
+func TestSegmentWriterAndCompaction(t *testing.T) {
+	client := newTestS3(t)
+	prefix := "test_segments/"
+
+	w := s3lib.NewSegmentWriter(client, prefix, 16, 0)
+	expected := make([]string, 0)
+	for i := 0; i < 10; i++ {
+		record := fmt.Sprintf("record-%d", i)
+		handleError(w.Write([]byte(record)), t)
+		expected = append(expected, record)
+	}
+	handleError(w.Close(), t)
+
+	keys, err := client.ListObjects(prefix)
+	handleError(err, t)
+	if len(keys) != 5 {
+		t.Errorf("expected 5 segments, got %d (%v)", len(keys), keys)
+	}
+	records := readSegmentRecords(t, client, prefix)
+	if fmt.Sprint(records) != fmt.Sprint(expected) {
+		t.Errorf("expected records %v, got %v", expected, records)
+	}
+
+	removed, err := client.CompactSegments(prefix, 64)
+	handleError(err, t)
+	if removed != 3 {
+		t.Errorf("expected 3 segments to be removed by compaction, got %d", removed)
+	}
+	records = readSegmentRecords(t, client, prefix)
+	if fmt.Sprint(records) != fmt.Sprint(expected) {
+		t.Errorf("expected records %v after compaction, got %v", expected, records)
+	}
+
+	keys, err = client.ListObjects(prefix)
+	handleError(err, t)
+	for _, key := range keys {
+		handleError(client.DeleteObject(key), t)
+	}
+}
+
+func TestSegmentWriterRollsByAge(t *testing.T) {
+	client := newTestS3(t)
+	prefix := "test_segments_age/"
+
+	w := s3lib.NewSegmentWriter(client, prefix, 0, 10*time.Millisecond)
+	handleError(w.Write([]byte("first")), t)
+	time.Sleep(20 * time.Millisecond)
+	handleError(w.Write([]byte("second")), t)
+	handleError(w.Flush(), t)
+
+	keys, err := client.ListObjects(prefix)
+	handleError(err, t)
+	if len(keys) != 2 {
+		t.Errorf("expected 2 segments, got %d (%v)", len(keys), keys)
+	}
+	for _, key := range keys {
+		handleError(client.DeleteObject(key), t)
+	}
+}
+
+func TestSegmentWriterInvalidRecord(t *testing.T) {
+	w := s3lib.NewSegmentWriter(s3lib.NewS3("bucket"), "prefix/", 0, 0)
+	if err := w.Write([]byte("multi\nline")); err != s3lib.ErrInvalidRecord {
+		t.Errorf("expected `%v`, got `%v`", s3lib.ErrInvalidRecord, err)
+	}
+}